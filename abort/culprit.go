@@ -0,0 +1,63 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+// Package abort holds the culprit reports that protocol drivers return when a party is caught misbehaving.
+// A report names the party, the proof and the check that failed, and the hash of the offending message, so that
+// the honest parties can compare and publish their reports as evidence for identifiable abort.
+
+package abort
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+type (
+	// Culprit is the evidence against one party
+	Culprit struct {
+		Party   int    // the culprit's position in the protocol's party list
+		Proof   string // the proof or value that failed, e.g. "RangeProofAlice"
+		Step    string // the check that failed
+		Detail  string // the underlying verification failure, e.g. a VerifyError, if known
+		MsgHash []byte // SHA512_256 of the offending message, see cmt.SHA512_256
+	}
+
+	// Error is returned by a protocol round that found one or more culprits
+	Error struct {
+		Task     string // the protocol, e.g. "signing"
+		Round    int
+		Culprits []*Culprit
+	}
+)
+
+func NewError(task string, round int, culprits ...*Culprit) *Error {
+	return &Error{Task: task, Round: round, Culprits: culprits}
+}
+
+func (c *Culprit) String() string {
+	if c.Detail != "" {
+		return fmt.Sprintf("party %d: %s failed at %q: %s (message %s)", c.Party, c.Proof, c.Step, c.Detail, hex.EncodeToString(c.MsgHash))
+	}
+	return fmt.Sprintf("party %d: %s failed at %q (message %s)", c.Party, c.Proof, c.Step, hex.EncodeToString(c.MsgHash))
+}
+
+func (err *Error) Error() string {
+	reports := make([]string, len(err.Culprits))
+	for i, c := range err.Culprits {
+		reports[i] = c.String()
+	}
+	return fmt.Sprintf("%s round %d: identified %d culprit(s): %s", err.Task, err.Round, len(err.Culprits), strings.Join(reports, "; "))
+}
+
+// Parties returns the positions of the culprits
+func (err *Error) Parties() []int {
+	out := make([]int, len(err.Culprits))
+	for i, c := range err.Culprits {
+		out[i] = c.Party
+	}
+	return out
+}
@@ -0,0 +1,69 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package abort
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// Malformed means the proof or the statement failed input validation before any verification step,
+	// e.g. a nil or out-of-range value. It may come from a faulty implementation as well as from a cheater.
+	Malformed ReasonKind = iota + 1
+	// Rejected means a verification step of the proof's figure failed, which a well-formed honest proof never does
+	Rejected
+)
+
+type (
+	ReasonKind int
+
+	// VerifyError is returned by the VerifyWithReason methods of the proofs
+	VerifyError struct {
+		Proof  string // e.g. "RangeProofAlice"
+		Kind   ReasonKind
+		Step   string // the failed step as numbered in the proof's figure; empty if Malformed
+		Detail string // the check that failed
+	}
+)
+
+func NewMalformedError(proof, detail string) *VerifyError {
+	return &VerifyError{Proof: proof, Kind: Malformed, Detail: detail}
+}
+
+func NewRejectedError(proof, step, detail string) *VerifyError {
+	return &VerifyError{Proof: proof, Kind: Rejected, Step: step, Detail: detail}
+}
+
+func (k ReasonKind) String() string {
+	switch k {
+	case Malformed:
+		return "malformed"
+	case Rejected:
+		return "rejected"
+	}
+	return fmt.Sprintf("ReasonKind(%d)", int(k))
+}
+
+func (err *VerifyError) Error() string {
+	if err.Kind == Rejected {
+		return fmt.Sprintf("%s: step %s failed: %s", err.Proof, err.Step, err.Detail)
+	}
+	return fmt.Sprintf("%s: %s: %s", err.Proof, err.Kind, err.Detail)
+}
+
+// IsMalformed reports whether err wraps a VerifyError for a malformed proof or statement
+func IsMalformed(err error) bool {
+	var ve *VerifyError
+	return errors.As(err, &ve) && ve.Kind == Malformed
+}
+
+// IsRejected reports whether err wraps a VerifyError for a failed verification step
+func IsRejected(err error) bool {
+	var ve *VerifyError
+	return errors.As(err, &ve) && ve.Kind == Rejected
+}
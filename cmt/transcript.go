@@ -0,0 +1,201 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package cmt
+
+import (
+	"crypto"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"strconv"
+)
+
+type (
+	// Transcript is a Fiat–Shamir transcript: the prover and the verifier append the same labelled messages
+	// and derive the same challenges from everything appended so far. Every message is framed with its label
+	// and length, so different sequences of messages never hash to the same input.
+	Transcript struct {
+		data []byte
+	}
+
+	// Session binds the proofs of one party in one protocol execution, so that they cannot be replayed in
+	// another session or attributed to another party. It is passed as the optional session argument of the
+	// provers and verifiers; the verifier must pass the prover's session.
+	//
+	// A nil session is allowed everywhere: the proofs then derive their challenges from the plain SHA512_256
+	// hashes they used before sessions existed, so they remain compatible with proofs made by earlier versions
+	// but are bound to nothing but their statement.
+	Session struct {
+		ID    []byte   // unique per protocol execution and agreed on by all parties
+		Party *big.Int // the prover's party index or Shamir ID
+	}
+
+	// Challenge lists what the Fiat–Shamir challenge of a proof is derived from
+	Challenge struct {
+		Domain     string // separates the proof from every other use of the transcript, e.g. "zk-proof/dln/v1"
+		Statement  []*big.Int
+		Commitment []*big.Int
+		// Legacy is the SHA512_256i input of the challenge without a session. If it is nil, the statement
+		// followed by the commitment is hashed.
+		Legacy []*big.Int
+	}
+)
+
+// NewTranscript starts a transcript separated from every other use of the hash by `domain`
+func NewTranscript(domain string) *Transcript {
+	t := &Transcript{}
+	t.AppendMessage("domain", []byte(domain))
+	return t
+}
+
+// AppendMessage appends a labelled message to the transcript
+func (t *Transcript) AppendMessage(label string, msg []byte) {
+	t.data = appendFramed(t.data, []byte(label))
+	t.data = appendFramed(t.data, msg)
+}
+
+// AppendInts appends a labelled list of non-negative integers to the transcript
+func (t *Transcript) AppendInts(label string, in ...*big.Int) {
+	bzs := make([]byte, 0, 8+len(in)*32)
+	bzs = appendUint64(bzs, uint64(len(in)))
+	for _, n := range in {
+		bzs = appendFramed(bzs, n.Bytes())
+	}
+	t.AppendMessage(label, bzs)
+}
+
+// ChallengeBytes derives n challenge bytes from the transcript, then appends them under `label`
+// so that the next challenge depends on this one
+func (t *Transcript) ChallengeBytes(label string, n int) []byte {
+	seed := appendFramed(append([]byte{}, t.data...), []byte(label))
+	out := make([]byte, 0, n+crypto.SHA512_256.Size())
+	for ctr := uint64(0); len(out) < n; ctr++ {
+		state := crypto.SHA512_256.New()
+		state.Write(seed)
+		state.Write(appendUint64(nil, ctr))
+		out = state.Sum(out)
+	}
+	out = out[:n]
+	t.AppendMessage(label, out)
+	return out
+}
+
+// ChallengeScalar derives a challenge in [0, q) from the transcript.
+// It samples 128 bits more than q has, so the reduction mod q is statistically close to uniform.
+func (t *Transcript) ChallengeScalar(label string, q *big.Int) *big.Int {
+	bzs := t.ChallengeBytes(label, (q.BitLen()+128+7)/8)
+	return RejectionSample(q, new(big.Int).SetBytes(bzs))
+}
+
+// ChallengeBits derives a challenge of `bits` independent bits from the transcript
+func (t *Transcript) ChallengeBits(label string, bits int) *big.Int {
+	bzs := t.ChallengeBytes(label, (bits+7)/8)
+	e := new(big.Int).SetBytes(bzs)
+	return e.Rsh(e, uint(len(bzs)*8-bits))
+}
+
+// ----- //
+
+func NewSession(id []byte, party *big.Int) (*Session, error) {
+	if len(id) == 0 || party == nil {
+		return nil, errors.New("NewSession: expected a non-empty session ID and a party")
+	}
+	return &Session{ID: id, Party: party}, nil
+}
+
+// Transcript starts a transcript for a proof with the given domain, bound to the session and the prover
+func (s *Session) Transcript(domain string) *Transcript {
+	t := NewTranscript(domain)
+	t.AppendMessage("session", s.ID)
+	t.AppendInts("party", s.Party)
+	return t
+}
+
+// Scalar derives a challenge in [0, q) for the session, which may be nil
+func (c *Challenge) Scalar(session *Session, q *big.Int) *big.Int {
+	if session == nil {
+		return RejectionSample(q, c.legacyHash())
+	}
+	return c.transcript(session).ChallengeScalar("challenge", q)
+}
+
+// Bits derives a challenge of `bits` independent bits for the session, which may be nil.
+// Without a session it is the whole hash, of which the proofs use at most HashLength bits.
+func (c *Challenge) Bits(session *Session, bits int) *big.Int {
+	if session == nil {
+		return c.legacyHash()
+	}
+	return c.transcript(session).ChallengeBits("challenge", bits)
+}
+
+// Candidates returns a source of `size`-byte challenges over the statement for proofs that draw several of them
+// and reject some. next(i, n) is the n-th candidate for the i-th challenge and must be called in order.
+// Without a session it concatenates SHA512_256(i, j, n, statement...) for the blocks j, as GenerateXs always did.
+func (c *Challenge) Candidates(session *Session, size int) (next func(i, n int) []byte) {
+	if session == nil {
+		statement := make([][]byte, len(c.Statement))
+		for k, in := range c.Statement {
+			statement[k] = in.Bytes()
+		}
+		blocks := (size + 31) / 32
+		return func(i, n int) []byte {
+			out := make([]byte, 0, blocks*32)
+			ib, nb := []byte(strconv.Itoa(i)), []byte(strconv.Itoa(n))
+			for j := 0; j < blocks; j++ {
+				in := append([][]byte{ib, []byte(strconv.Itoa(j)), nb}, statement...)
+				hash := SHA512_256(in...)
+				if hash == nil { // this should never happen. see: https://golang.org/pkg/hash/#Hash
+					panic(errors.New("Candidates hash write error!"))
+				}
+				out = append(out, hash...)
+			}
+			return out[:size]
+		}
+	}
+	t := c.transcript(session)
+	return func(int, int) []byte {
+		// each candidate is appended to the transcript, so they are all different
+		return t.ChallengeBytes("challenge", size)
+	}
+}
+
+func (c *Challenge) transcript(session *Session) *Transcript {
+	t := session.Transcript(c.Domain)
+	t.AppendInts("statement", c.Statement...)
+	t.AppendInts("commitment", c.Commitment...)
+	return t
+}
+
+func (c *Challenge) legacyHash() *big.Int {
+	if c.Legacy != nil {
+		return SHA512_256i(c.Legacy...)
+	}
+	return SHA512_256i(append(append([]*big.Int{}, c.Statement...), c.Commitment...)...)
+}
+
+// OptionalSession returns the session passed as an optional argument, or nil if there is none
+func OptionalSession(optionalSession []*Session) *Session {
+	if len(optionalSession) == 0 {
+		return nil
+	}
+	if 1 < len(optionalSession) {
+		panic(errors.New("expected 0 or 1 item in `optionalSession`"))
+	}
+	return optionalSession[0]
+}
+
+// ----- utils
+
+func appendUint64(bz []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(bz, buf[:]...)
+}
+
+func appendFramed(bz, msg []byte) []byte {
+	return append(appendUint64(bz, uint64(len(msg))), msg...)
+}
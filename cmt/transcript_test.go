@@ -0,0 +1,114 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package cmt
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestTranscript(t *testing.T) {
+	q := new(big.Int).Lsh(big.NewInt(1), 255)
+	q = q.Sub(q, big.NewInt(19))
+	newT := func() *Transcript {
+		tr := NewTranscript("test")
+		tr.AppendInts("ints", big.NewInt(1), big.NewInt(2))
+		return tr
+	}
+
+	if newT().ChallengeScalar("e", q).Cmp(newT().ChallengeScalar("e", q)) != 0 {
+		t.Fatal("the same transcript gave different challenges")
+	}
+	if newT().ChallengeScalar("e", q).Cmp(newT().ChallengeScalar("f", q)) == 0 {
+		t.Error("different challenge labels gave the same challenge")
+	}
+	// framing: moving bytes between messages must change the challenge
+	t1, t2 := NewTranscript("test"), NewTranscript("test")
+	t1.AppendMessage("a", []byte("bc"))
+	t2.AppendMessage("ab", []byte("c"))
+	if bytes.Equal(t1.ChallengeBytes("e", 32), t2.ChallengeBytes("e", 32)) {
+		t.Error("differently framed messages gave the same challenge")
+	}
+
+	tr := newT()
+	e1, e2 := tr.ChallengeScalar("e", q), tr.ChallengeScalar("e", q)
+	if e1.Cmp(e2) == 0 {
+		t.Error("successive challenges were equal")
+	}
+	if e1.Sign() == -1 || e1.Cmp(q) != -1 {
+		t.Error("challenge scalar out of range")
+	}
+	if bits := tr.ChallengeBits("c", 128); bits.BitLen() > 128 {
+		t.Errorf("expected at most 128 bits but got %d", bits.BitLen())
+	}
+	if n := len(tr.ChallengeBytes("long", 100)); n != 100 {
+		t.Errorf("expected 100 bytes but got %d", n)
+	}
+}
+
+func TestSessionTranscript(t *testing.T) {
+	s1, err := NewSession([]byte("session-1"), big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2, _ := NewSession([]byte("session-1"), big.NewInt(2))
+	s3, _ := NewSession([]byte("session-2"), big.NewInt(1))
+	e1 := s1.Transcript("test").ChallengeBytes("e", 32)
+	if bytes.Equal(e1, s2.Transcript("test").ChallengeBytes("e", 32)) {
+		t.Error("different parties gave the same challenge")
+	}
+	if bytes.Equal(e1, s3.Transcript("test").ChallengeBytes("e", 32)) {
+		t.Error("different sessions gave the same challenge")
+	}
+	if bytes.Equal(e1, s1.Transcript("other").ChallengeBytes("e", 32)) {
+		t.Error("different domains gave the same challenge")
+	}
+	if _, err = NewSession(nil, big.NewInt(1)); err == nil {
+		t.Error("expected an error for an empty session ID")
+	}
+}
+
+func TestChallenge(t *testing.T) {
+	q := new(big.Int).Lsh(big.NewInt(1), 255)
+	q = q.Sub(q, big.NewInt(19))
+	one, two, three := big.NewInt(1), big.NewInt(2), big.NewInt(3)
+	ch := &Challenge{Domain: "test", Statement: []*big.Int{one, two}, Commitment: []*big.Int{three}}
+	session, _ := NewSession([]byte("session"), one)
+
+	// without a session the statement and commitment are hashed as before, or Legacy if it is set
+	if ch.Bits(nil, HashLength).Cmp(SHA512_256i(one, two, three)) != 0 {
+		t.Error("the challenge without a session is not the hash of the statement and commitment")
+	}
+	if ch.Scalar(nil, q).Cmp(RejectionSample(q, SHA512_256i(one, two, three))) != 0 {
+		t.Error("the scalar challenge without a session is not the rejection sample of the hash")
+	}
+	legacy := &Challenge{Domain: "test", Statement: ch.Statement, Commitment: ch.Commitment, Legacy: []*big.Int{three}}
+	if legacy.Bits(nil, HashLength).Cmp(SHA512_256i(three)) != 0 {
+		t.Error("the challenge without a session ignored Legacy")
+	}
+	if ch.Scalar(session, q).Cmp(ch.Scalar(nil, q)) == 0 {
+		t.Error("the session did not change the challenge")
+	}
+	if ch.Scalar(session, q).Cmp(legacy.Scalar(session, q)) != 0 {
+		t.Error("Legacy changed the challenge of a session")
+	}
+	if bits := ch.Bits(session, 80); bits.BitLen() > 80 {
+		t.Errorf("expected at most 80 bits but got %d", bits.BitLen())
+	}
+
+	for _, s := range []*Session{nil, session} {
+		next := ch.Candidates(s, 40)
+		c1, c2 := next(0, 0), next(0, 1)
+		if len(c1) != 40 || len(c2) != 40 {
+			t.Fatalf("expected 40-byte candidates but got %d and %d", len(c1), len(c2))
+		}
+		if bytes.Equal(c1, c2) {
+			t.Error("successive candidates were equal")
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"fmt"
 	"github.com/pkg/errors"
+	"io"
 	"math/big"
 )
 
@@ -19,19 +20,24 @@ const (
 
 // MustGetRandomInt panics if it is unable to gather entropy from `rand.Reader` or when `bits` is <= 0
 func MustGetRandomInt(bits int) *big.Int {
+	n, err := GetRandomIntWithReader(rand.Reader, bits)
+	if err != nil {
+		panic(errors.Wrap(err, "rand.Int failure in MustGetRandomInt!"))
+	}
+	return n
+}
+
+// GetRandomIntWithReader draws a random int of at most `bits` bits from `reader`, which may be a deterministic source in tests
+func GetRandomIntWithReader(reader io.Reader, bits int) (*big.Int, error) {
 	if bits <= 0 || mustGetRandomIntMaxBits < bits {
-		panic(fmt.Errorf("MustGetRandomInt: bits should be positive, non-zero and less than %d", mustGetRandomIntMaxBits))
+		return nil, fmt.Errorf("GetRandomIntWithReader: bits should be positive, non-zero and less than %d", mustGetRandomIntMaxBits)
 	}
 	// Max random value e.g. 2^256 - 1
 	max := new(big.Int)
 	max = max.Exp(two, big.NewInt(int64(bits)), nil).Sub(max, one)
 
-	// Generate cryptographically strong pseudo-random int between 0 - max
-	n, err := rand.Int(rand.Reader, max)
-	if err != nil {
-		panic(errors.Wrap(err, "rand.Int failure in MustGetRandomInt!"))
-	}
-	return n
+	// Generate pseudo-random int between 0 - max
+	return rand.Int(reader, max)
 }
 
 func GetRandomPositiveInt(lessThan *big.Int) *big.Int {
@@ -48,6 +54,22 @@ func GetRandomPositiveInt(lessThan *big.Int) *big.Int {
 	return try
 }
 
+// GetRandomPositiveIntWithReader is GetRandomPositiveInt drawing its entropy from `reader`
+func GetRandomPositiveIntWithReader(reader io.Reader, lessThan *big.Int) (*big.Int, error) {
+	if lessThan == nil || zero.Cmp(lessThan) != -1 {
+		return nil, errors.New("GetRandomPositiveIntWithReader: lessThan should be positive")
+	}
+	for {
+		try, err := GetRandomIntWithReader(reader, lessThan.BitLen())
+		if err != nil {
+			return nil, err
+		}
+		if try.Cmp(lessThan) < 0 && try.Cmp(zero) >= 0 {
+			return try, nil
+		}
+	}
+}
+
 func BigIntsToBytes(bigInts []*big.Int) [][]byte {
 	bzs := make([][]byte, len(bigInts))
 	for i := range bzs {
@@ -105,6 +127,22 @@ func GetRandomPositiveRelativelyPrimeInt(n *big.Int) *big.Int {
 	return try
 }
 
+// GetRandomPositiveRelativelyPrimeIntWithReader is GetRandomPositiveRelativelyPrimeInt drawing its entropy from `reader`
+func GetRandomPositiveRelativelyPrimeIntWithReader(reader io.Reader, n *big.Int) (*big.Int, error) {
+	if n == nil || zero.Cmp(n) != -1 {
+		return nil, errors.New("GetRandomPositiveRelativelyPrimeIntWithReader: n should be positive")
+	}
+	for {
+		try, err := GetRandomIntWithReader(reader, n.BitLen())
+		if err != nil {
+			return nil, err
+		}
+		if IsNumberInMultiplicativeGroup(n, try) {
+			return try, nil
+		}
+	}
+}
+
 func IsNumberInMultiplicativeGroup(n, v *big.Int) bool {
 	if n == nil || v == nil || zero.Cmp(n) != -1 {
 		return false
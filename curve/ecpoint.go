@@ -82,6 +82,17 @@ func (p *ECPoint) ValidateBasic() bool {
 	return p != nil && p.coords[0] != nil && p.coords[1] != nil && p.IsOnCurve()
 }
 
+// IsIdentity reports whether p is the point at infinity, which crypto/elliptic encodes as (0, 0) and the edwards curve as (0, 1)
+func (p *ECPoint) IsIdentity() bool {
+	if p.coords[0].Sign() != 0 {
+		return false
+	}
+	if _, ok := p.curve.(*edwards.TwistedEdwardsCurve); ok {
+		return p.coords[1].Cmp(big.NewInt(1)) == 0
+	}
+	return p.coords[1].Sign() == 0
+}
+
 func (p *ECPoint) EightInvEight() *ECPoint {
 	return p.ScalarMult(eight).ScalarMult(eightInv)
 }
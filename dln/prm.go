@@ -0,0 +1,157 @@
+// Copyright © 2019-2020 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+// Ring-Pedersen parameter proof Πprm from CGGMP21 Fig. 17
+
+// A proof that s = t^λ mod N for a secret λ, i.e. that s lies in the group generated by t.
+// Unlike the DLN pair it is a single proof, and it sends the challenge instead of the commitments A_i,
+// which the verifier recomputes as t^z_i * s^-e_i. It serialises to iterations + 1 elements.
+
+package dln
+
+import (
+	"errors"
+	"fmt"
+	"github.com/zhp12543/zk-proof/abort"
+	"github.com/zhp12543/zk-proof/cmt"
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/prime"
+	"math/big"
+)
+
+const (
+	// PrmIterations is the default statistical security parameter of PrmProof
+	PrmIterations = 80
+	// PrmMaxIterations is bounded by the challenge length
+	PrmMaxIterations = cmt.HashLength
+)
+
+type (
+	PrmProof struct {
+		E *big.Int   // Fiat–Shamir challenge; bit i is e_i
+		Z []*big.Int // z_i = a_i + e_i * lambda mod pq
+	}
+)
+
+// NewPrmProof proves s = t^lambda mod N, where N = (2p+1)(2q+1) and t is a quadratic residue so its order divides pq.
+// iterations is the statistical security parameter, usually PrmIterations.
+func NewPrmProof(s, t, lambda, p, q, N *big.Int, iterations int, optionalSession ...*cmt.Session) (*PrmProof, error) {
+	if s == nil || t == nil || lambda == nil || p == nil || q == nil || N == nil {
+		return nil, errors.New("NewPrmProof received nil value(s)")
+	}
+	if err := checkPrmIterations(iterations); err != nil {
+		return nil, err
+	}
+	pMulQ := new(big.Int).Mul(p, q)
+	modN, modPQ := prime.ModInt(N), prime.ModInt(pMulQ)
+	a := make([]*big.Int, iterations)
+	A := make([]*big.Int, iterations)
+	for i := range A {
+		a[i] = curve.GetRandomPositiveInt(pMulQ)
+		A[i] = modN.Exp(t, a[i])
+	}
+	e := prmChallenge(s, t, N, A, cmt.OptionalSession(optionalSession))
+	z := make([]*big.Int, iterations)
+	eI := new(big.Int)
+	for i := range z {
+		eI = eI.SetInt64(int64(e.Bit(i)))
+		z[i] = modPQ.Add(a[i], modPQ.Mul(eI, lambda))
+	}
+	return &PrmProof{E: e, Z: z}, nil
+}
+
+// Verify checks the proof for s = t^lambda mod N. Proofs made with a different number of iterations are rejected.
+func (pf *PrmProof) Verify(s, t, N *big.Int, iterations int, optionalSession ...*cmt.Session) bool {
+	return pf.VerifyWithReason(s, t, N, iterations, optionalSession...) == nil
+}
+
+// VerifyWithReason is Verify returning an *abort.VerifyError that names the failed check of CGGMP21 Fig. 17
+func (pf *PrmProof) VerifyWithReason(s, t, N *big.Int, iterations int, optionalSession ...*cmt.Session) error {
+	const name = "PrmProof"
+	if pf == nil || pf.E == nil || s == nil || t == nil || N == nil {
+		return abort.NewMalformedError(name, "nil proof or statement value(s)")
+	}
+	if checkPrmIterations(iterations) != nil || len(pf.Z) != iterations {
+		return abort.NewMalformedError(name, "unexpected number of iterations")
+	}
+	if N.Sign() != 1 {
+		return abort.NewMalformedError(name, "N is not positive")
+	}
+	s_ := new(big.Int).Mod(s, N)
+	if s_.Cmp(one) != 1 || !curve.IsNumberInMultiplicativeGroup(N, s_) {
+		return abort.NewMalformedError(name, "s is 1 or not in Z*_N")
+	}
+	t_ := new(big.Int).Mod(t, N)
+	if t_.Cmp(one) != 1 || !curve.IsNumberInMultiplicativeGroup(N, t_) {
+		return abort.NewMalformedError(name, "t is 1 or not in Z*_N")
+	}
+	if s_.Cmp(t_) == 0 {
+		return abort.NewMalformedError(name, "s = t")
+	}
+	if pf.E.Sign() == -1 || pf.E.BitLen() > PrmMaxIterations {
+		return abort.NewMalformedError(name, "e has more than PrmMaxIterations bits")
+	}
+	modN := prime.ModInt(N)
+	sInv := modN.ModInverse(s)
+	A := make([]*big.Int, iterations)
+	for i := range A {
+		if pf.Z[i] == nil || pf.Z[i].Sign() == -1 || pf.Z[i].Cmp(N) != -1 {
+			return abort.NewMalformedError(name, fmt.Sprintf("z_%d is not in [0, N)", i))
+		}
+		// A_i = t^z_i * s^-e_i
+		A[i] = modN.Exp(t, pf.Z[i])
+		if pf.E.Bit(i) == 1 {
+			A[i] = modN.Mul(A[i], sInv)
+		}
+	}
+	if prmChallenge(s, t, N, A, cmt.OptionalSession(optionalSession)).Cmp(pf.E) != 0 {
+		return abort.NewRejectedError(name, "challenge check", "e does not match the challenge of A_i = t^z_i * s^-e_i")
+	}
+	return nil
+}
+
+func (pf *PrmProof) Serialize() ([][]byte, error) {
+	if pf == nil || pf.E == nil {
+		return nil, errors.New("PrmProof.Serialize received a nil proof")
+	}
+	bzs := make([][]byte, 0, len(pf.Z)+1)
+	bzs = append(bzs, pf.E.Bytes())
+	for _, z := range pf.Z {
+		if z == nil {
+			return nil, errors.New("PrmProof.Serialize found a nil z")
+		}
+		bzs = append(bzs, z.Bytes())
+	}
+	return bzs, nil
+}
+
+func UnmarshalPrmProof(bzs [][]byte) (*PrmProof, error) {
+	if len(bzs) < 2 || PrmMaxIterations+1 < len(bzs) {
+		return nil, fmt.Errorf("UnmarshalPrmProof expected between %d and %d parts but got %d", 2, PrmMaxIterations+1, len(bzs))
+	}
+	ints := curve.MultiBytesToBigInts(bzs)
+	return &PrmProof{E: ints[0], Z: ints[1:]}, nil
+}
+
+// ----- utils
+
+func checkPrmIterations(iterations int) error {
+	if iterations < 1 || PrmMaxIterations < iterations {
+		return fmt.Errorf("iterations should be between 1 and %d", PrmMaxIterations)
+	}
+	return nil
+}
+
+// prmChallenge derives one challenge bit e_i per commitment A_i
+func prmChallenge(s, t, N *big.Int, A []*big.Int, session *cmt.Session) *big.Int {
+	ch := &cmt.Challenge{
+		Domain:     "zk-proof/dln/prm/v1",
+		Statement:  []*big.Int{t, s, N},
+		Commitment: A,
+		Legacy:     append([]*big.Int{t, s, N, big.NewInt(int64(len(A)))}, A...),
+	}
+	return ch.Bits(session, len(A))
+}
@@ -0,0 +1,108 @@
+// Copyright © 2019-2020 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package dln
+
+import (
+	"context"
+	"github.com/zhp12543/zk-proof/abort"
+	"github.com/zhp12543/zk-proof/cmt"
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/prime"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// ring-Pedersen parameters over a small NTilde, generated like proof.GeneratePreParams does
+func setUpRingPedersen(t *testing.T) (h1, h2, alpha, p, q, N *big.Int) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	sgps, err := prime.GetRandomSafePrimesConcurrent(ctx, 512, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	N = new(big.Int).Mul(sgps[0].SafePrime(), sgps[1].SafePrime())
+	p, q = sgps[0].Prime(), sgps[1].Prime()
+	f1 := curve.GetRandomPositiveRelativelyPrimeInt(N)
+	alpha = curve.GetRandomPositiveRelativelyPrimeInt(N)
+	h1 = prime.ModInt(N).Mul(f1, f1)
+	h2 = prime.ModInt(N).Exp(h1, alpha)
+	return
+}
+
+func TestPrmProof(t *testing.T) {
+	h1, h2, alpha, p, q, N := setUpRingPedersen(t)
+	for _, iterations := range []int{PrmIterations, 128} {
+		pf, err := NewPrmProof(h2, h1, alpha, p, q, N, iterations)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bzs, err := pf.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(bzs) != iterations+1 {
+			t.Fatalf("expected %d parts but got %d", iterations+1, len(bzs))
+		}
+		pf2, err := UnmarshalPrmProof(bzs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !pf2.Verify(h2, h1, N, iterations) {
+			t.Fatal("PrmProof did not verify")
+		}
+		if pf2.Verify(h1, h2, N, iterations) {
+			t.Error("PrmProof verified with swapped bases")
+		}
+	}
+
+	pf, err := NewPrmProof(h2, h1, alpha, p, q, N, PrmIterations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pf.Verify(h2, h1, N, 128) {
+		t.Error("PrmProof verified with a different iteration count")
+	}
+	if err := pf.VerifyWithReason(h2, h2, N, PrmIterations); !abort.IsMalformed(err) {
+		t.Errorf("expected s = t to be malformed, got %v", err)
+	}
+	bad := *pf
+	bad.Z = append([]*big.Int{new(big.Int).Add(pf.Z[0], one)}, pf.Z[1:]...)
+	if err := bad.VerifyWithReason(h2, h1, N, PrmIterations); !abort.IsRejected(err) {
+		t.Errorf("expected a tampered z_0 to be rejected, got %v", err)
+	}
+	wrong := new(big.Int).Add(alpha, one)
+	pf, err = NewPrmProof(h2, h1, wrong, p, q, N, PrmIterations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pf.Verify(h2, h1, N, PrmIterations) {
+		t.Error("PrmProof verified with a wrong witness")
+	}
+	if _, err := NewPrmProof(h2, h1, alpha, p, q, N, PrmMaxIterations+1); err == nil {
+		t.Error("expected an error for too many iterations")
+	}
+}
+
+func TestPrmProofSession(t *testing.T) {
+	h1, h2, alpha, p, q, N := setUpRingPedersen(t)
+	session, _ := cmt.NewSession([]byte("session"), big.NewInt(1))
+	other, _ := cmt.NewSession([]byte("session"), big.NewInt(2))
+	pf, err := NewPrmProof(h2, h1, alpha, p, q, N, 128, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pf.Verify(h2, h1, N, 128, session) {
+		t.Fatal("proof did not verify in its session")
+	}
+	if pf.Verify(h2, h1, N, 128, other) || pf.Verify(h2, h1, N, 128) {
+		t.Error("proof verified outside its session")
+	}
+	if err := pf.VerifyWithReason(h2, h1, N, 128, other); !abort.IsRejected(err) {
+		t.Errorf("expected another party's session to be rejected, got %v", err)
+	}
+}
@@ -13,6 +13,7 @@ package dln
 
 import (
 	"fmt"
+	"github.com/zhp12543/zk-proof/abort"
 	"github.com/zhp12543/zk-proof/cmt"
 	"github.com/zhp12543/zk-proof/curve"
 	"github.com/zhp12543/zk-proof/prime"
@@ -32,7 +33,8 @@ var (
 	one = big.NewInt(1)
 )
 
-func NewDLNProof(h1, h2, x, p, q, N *big.Int) *Proof {
+// NewDLNProof proves h2 = h1^x mod N.
+func NewDLNProof(h1, h2, x, p, q, N *big.Int, optionalSession ...*cmt.Session) *Proof {
 	pMulQ := new(big.Int).Mul(p, q)
 	modN, modPQ := prime.ModInt(N), prime.ModInt(pMulQ)
 	a := make([]*big.Int, Iterations)
@@ -41,8 +43,7 @@ func NewDLNProof(h1, h2, x, p, q, N *big.Int) *Proof {
 		a[i] = curve.GetRandomPositiveInt(pMulQ)
 		alpha[i] = modN.Exp(h1, a[i])
 	}
-	msg := append([]*big.Int{h1, h2, N}, alpha[:]...)
-	c := cmt.SHA512_256i(msg...)
+	c := dlnChallenge(h1, h2, N, alpha[:], cmt.OptionalSession(optionalSession))
 	t := [Iterations]*big.Int{}
 	cIBI := new(big.Int)
 	for i := range t {
@@ -53,54 +54,61 @@ func NewDLNProof(h1, h2, x, p, q, N *big.Int) *Proof {
 	return &Proof{alpha, t}
 }
 
-func (p *Proof) Verify(h1, h2, N *big.Int) bool {
-	if p == nil {
-		return false
+func (p *Proof) Verify(h1, h2, N *big.Int, optionalSession ...*cmt.Session) bool {
+	return p.VerifyWithReason(h1, h2, N, optionalSession...) == nil
+}
+
+// VerifyWithReason is Verify returning an *abort.VerifyError; a failed step names the iteration whose check failed
+func (p *Proof) VerifyWithReason(h1, h2, N *big.Int, optionalSession ...*cmt.Session) error {
+	const name = "DLNProof"
+	if p == nil || h1 == nil || h2 == nil || N == nil {
+		return abort.NewMalformedError(name, "nil proof or statement value(s)")
 	}
 	if N.Sign() != 1 {
-		return false
+		return abort.NewMalformedError(name, "N is not positive")
 	}
 	modN := prime.ModInt(N)
 	h1_ := new(big.Int).Mod(h1, N)
 	if h1_.Cmp(one) != 1 || h1_.Cmp(N) != -1 {
-		return false
+		return abort.NewMalformedError(name, "h1 is not in (1, N)")
 	}
 	h2_ := new(big.Int).Mod(h2, N)
 	if h2_.Cmp(one) != 1 || h2_.Cmp(N) != -1 {
-		return false
+		return abort.NewMalformedError(name, "h2 is not in (1, N)")
 	}
 	if h1_.Cmp(h2_) == 0 {
-		return false
+		return abort.NewMalformedError(name, "h1 = h2")
+	}
+	for i := 0; i < Iterations; i++ {
+		if p.Alpha[i] == nil || p.T[i] == nil {
+			return abort.NewMalformedError(name, fmt.Sprintf("nil alpha_%d or t_%d", i, i))
+		}
 	}
 	for i := range p.T {
 		a := new(big.Int).Mod(p.T[i], N)
 		if a.Cmp(one) != 1 || a.Cmp(N) != -1 {
-			return false
+			return abort.NewMalformedError(name, fmt.Sprintf("t_%d is not in (1, N)", i))
 		}
 	}
 	for i := range p.Alpha {
 		a := new(big.Int).Mod(p.Alpha[i], N)
 		if a.Cmp(one) != 1 || a.Cmp(N) != -1 {
-			return false
+			return abort.NewMalformedError(name, fmt.Sprintf("alpha_%d is not in (1, N)", i))
 		}
 	}
-	msg := append([]*big.Int{h1, h2, N}, p.Alpha[:]...)
-	c := cmt.SHA512_256i(msg...)
+	c := dlnChallenge(h1, h2, N, p.Alpha[:], cmt.OptionalSession(optionalSession))
 	cIBI := new(big.Int)
 	for i := 0; i < Iterations; i++ {
-		if p.Alpha[i] == nil || p.T[i] == nil {
-			return false
-		}
 		cI := c.Bit(i)
 		cIBI = cIBI.SetInt64(int64(cI))
 		h1ExpTi := modN.Exp(h1, p.T[i])
 		h2ExpCi := modN.Exp(h2, cIBI)
 		alphaIMulH2ExpCi := modN.Mul(p.Alpha[i], h2ExpCi)
 		if h1ExpTi.Cmp(alphaIMulH2ExpCi) != 0 {
-			return false
+			return abort.NewRejectedError(name, fmt.Sprintf("iteration %d", i), "h1^t_i != alpha_i * h2^c_i mod N")
 		}
 	}
-	return true
+	return nil
 }
 
 func (p *Proof) Serialize() ([][]byte, error) {
@@ -143,3 +151,15 @@ func UnmarshalDLNProof(bzs [][]byte) (*Proof, error) {
 	}
 	return pf, nil
 }
+
+// ----- utils
+
+// dlnChallenge derives the challenge bits c_i
+func dlnChallenge(h1, h2, N *big.Int, alpha []*big.Int, session *cmt.Session) *big.Int {
+	ch := &cmt.Challenge{
+		Domain:     "zk-proof/dln/v1",
+		Statement:  []*big.Int{h1, h2, N},
+		Commitment: alpha,
+	}
+	return ch.Bits(session, Iterations)
+}
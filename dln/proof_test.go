@@ -0,0 +1,47 @@
+// Copyright © 2019-2020 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package dln
+
+import (
+	"github.com/zhp12543/zk-proof/abort"
+	"github.com/zhp12543/zk-proof/cmt"
+	"math/big"
+	"testing"
+)
+
+func TestProofVerifyWithReason(t *testing.T) {
+	h1, h2, alpha, p, q, N := setUpRingPedersen(t)
+	pf := NewDLNProof(h1, h2, alpha, p, q, N)
+	if err := pf.VerifyWithReason(h1, h2, N); err != nil {
+		t.Fatalf("valid proof was rejected: %v", err)
+	}
+	if err := pf.VerifyWithReason(h1, h1, N); !abort.IsMalformed(err) {
+		t.Errorf("expected h1 = h2 to be malformed, got %v", err)
+	}
+	bad := *pf
+	bad.T[3] = new(big.Int).Add(pf.T[3], big.NewInt(1))
+	if err := bad.VerifyWithReason(h1, h2, N); !abort.IsRejected(err) {
+		t.Errorf("expected a tampered t_i to be rejected, got %v", err)
+	}
+	bad.T[3] = nil
+	if err := bad.VerifyWithReason(h1, h2, N); !abort.IsMalformed(err) {
+		t.Errorf("expected a nil t_i to be malformed, got %v", err)
+	}
+}
+
+func TestProofSession(t *testing.T) {
+	h1, h2, alpha, p, q, N := setUpRingPedersen(t)
+	session, _ := cmt.NewSession([]byte("session"), big.NewInt(1))
+	other, _ := cmt.NewSession([]byte("other session"), big.NewInt(1))
+	pf := NewDLNProof(h1, h2, alpha, p, q, N, session)
+	if !pf.Verify(h1, h2, N, session) {
+		t.Fatal("proof did not verify in its session")
+	}
+	if pf.Verify(h1, h2, N, other) || pf.Verify(h1, h2, N) {
+		t.Error("proof verified outside its session")
+	}
+}
@@ -0,0 +1,27 @@
+package encproof
+
+import (
+	"errors"
+	"math/big"
+)
+
+func (pf *ProofEnc) Flat() []*big.Int {
+	return []*big.Int{
+		pf.S,
+		pf.A,
+		pf.C,
+		pf.Z1,
+		pf.Z2,
+		pf.Z3,
+	}
+}
+
+func ProofEncUnFlat(in []*big.Int) (*ProofEnc, error) {
+	if len(in) != ProofEncBytesParts {
+		return nil, errors.New("ProofEncUnFlat len error")
+	}
+
+	return &ProofEnc{
+		S: in[0], A: in[1], C: in[2], Z1: in[3], Z2: in[4], Z3: in[5],
+	}, nil
+}
@@ -0,0 +1,199 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+// Paillier encryption-in-range proof Πenc from CGGMP21 Fig. 14
+
+// Proves that a Paillier ciphertext K = (1+N0)^k * rho^N0 mod N0^2 encrypts k < 2^ℓ, using the verifier's
+// ring-Pedersen parameters NCap, s, t. The bound is given in bits rather than derived from an elliptic curve.
+
+package encproof
+
+import (
+	"errors"
+	"fmt"
+	"github.com/zhp12543/zk-proof/abort"
+	"github.com/zhp12543/zk-proof/cmt"
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/paillier"
+	"github.com/zhp12543/zk-proof/prime"
+	"math/big"
+)
+
+const (
+	ProofEncBytesParts = 6
+	// ChallengeBits is the length of the Fiat–Shamir challenge e
+	ChallengeBits = cmt.HashLength
+)
+
+type (
+	// Params are the range ℓ and the slack ε, in bits. The proof shows k < 2^(ℓ+ε) for an honest k < 2^ℓ,
+	// and the prover retries with probability about 2^(ChallengeBits-ε), so ε should exceed ChallengeBits
+	// by the statistical security parameter.
+	Params struct {
+		L, Epsilon int
+	}
+
+	ProofEnc struct {
+		S, A, C, Z1, Z2, Z3 *big.Int
+	}
+)
+
+var (
+	one = big.NewInt(1)
+)
+
+func (params Params) Validate() error {
+	if params.L < 1 || params.Epsilon <= ChallengeBits {
+		return fmt.Errorf("encproof params: expected ℓ > 0 and ε > %d but got ℓ = %d, ε = %d", ChallengeBits, params.L, params.Epsilon)
+	}
+	return nil
+}
+
+// NewProof implements proofEnc for K = Enc_pk(k; rho)
+func NewProof(params Params, pk *paillier.PublicKey, NCap, s, t, K, k, rho *big.Int, optionalSession ...*cmt.Session) (*ProofEnc, error) {
+	if pk == nil || NCap == nil || s == nil || t == nil || K == nil || k == nil || rho == nil {
+		return nil, errors.New("ProveEnc constructor received nil value(s)")
+	}
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	twoL := new(big.Int).Lsh(one, uint(params.L))
+	if !prime.IsInInterval(k, twoL) {
+		return nil, fmt.Errorf("ProveEnc: k is not in [0, 2^%d)", params.L)
+	}
+	twoLEps := new(big.Int).Lsh(one, uint(params.L+params.Epsilon))
+	twoLNCap := new(big.Int).Mul(twoL, NCap)
+	twoLEpsNCap := new(big.Int).Mul(twoLEps, NCap)
+	N0 := pk.N
+	modNCap, modN0Squared, modN0 := prime.ModInt(NCap), prime.ModInt(pk.NSquare()), prime.ModInt(N0)
+
+	for {
+		// Fig 14.1 sample
+		alpha := curve.GetRandomPositiveInt(twoLEps)
+		mu := curve.GetRandomPositiveInt(twoLNCap)
+		r := curve.GetRandomPositiveRelativelyPrimeInt(N0)
+		gamma := curve.GetRandomPositiveInt(twoLEpsNCap)
+
+		// Fig 14.1 compute
+		S := modNCap.Mul(modNCap.Exp(s, k), modNCap.Exp(t, mu))
+		A := modN0Squared.Mul(modN0Squared.Exp(pk.Gamma(), alpha), modN0Squared.Exp(r, N0))
+		C := modNCap.Mul(modNCap.Exp(s, alpha), modNCap.Exp(t, gamma))
+
+		// Fig 14.2 e
+		e := challenge(params, pk, NCap, s, t, K, S, A, C, cmt.OptionalSession(optionalSession))
+
+		// Fig 14.3
+		z1 := new(big.Int).Mul(e, k)
+		z1 = z1.Add(z1, alpha)
+		if !prime.IsInInterval(z1, twoLEps) {
+			continue // would fail the range check; resample
+		}
+		z2 := modN0.Mul(r, modN0.Exp(rho, e))
+		z3 := new(big.Int).Mul(e, mu)
+		z3 = z3.Add(z3, gamma)
+		return &ProofEnc{S: S, A: A, C: C, Z1: z1, Z2: z2, Z3: z3}, nil
+	}
+}
+
+func NewProofFromBytes(bzs [][]byte) (*ProofEnc, error) {
+	if !curve.NonEmptyMultiBytes(bzs, ProofEncBytesParts) {
+		return nil, fmt.Errorf("expected %d byte parts to construct ProofEnc", ProofEncBytesParts)
+	}
+	return ProofEncUnFlat(curve.MultiBytesToBigInts(bzs))
+}
+
+func (pf *ProofEnc) Verify(params Params, pk *paillier.PublicKey, NCap, s, t, K *big.Int, optionalSession ...*cmt.Session) bool {
+	return pf.VerifyWithReason(params, pk, NCap, s, t, K, optionalSession...) == nil
+}
+
+// VerifyWithReason is Verify returning an *abort.VerifyError that names the failed check of CGGMP21 Fig. 14
+func (pf *ProofEnc) VerifyWithReason(params Params, pk *paillier.PublicKey, NCap, s, t, K *big.Int, optionalSession ...*cmt.Session) error {
+	const name = "ProofEnc"
+	if pf == nil || !pf.ValidateBasic() || pk == nil || NCap == nil || s == nil || t == nil || K == nil {
+		return abort.NewMalformedError(name, "nil proof or statement value(s)")
+	}
+	if err := params.Validate(); err != nil {
+		return abort.NewMalformedError(name, err.Error())
+	}
+	if NCap.Sign() != 1 {
+		return abort.NewMalformedError(name, "NCap is not positive")
+	}
+	N0, N0Squared := pk.N, pk.NSquare()
+	if !curve.IsNumberInMultiplicativeGroup(N0Squared, K) {
+		return abort.NewMalformedError(name, "K is not in Z*_N0^2")
+	}
+	if !curve.IsNumberInMultiplicativeGroup(NCap, pf.S) || !curve.IsNumberInMultiplicativeGroup(NCap, pf.C) {
+		return abort.NewMalformedError(name, "S or C is not in Z*_NCap")
+	}
+	if !curve.IsNumberInMultiplicativeGroup(N0Squared, pf.A) || !curve.IsNumberInMultiplicativeGroup(N0, pf.Z2) {
+		return abort.NewMalformedError(name, "A is not in Z*_N0^2 or z2 is not in Z*_N0")
+	}
+	if pf.Z3.Sign() == -1 {
+		return abort.NewMalformedError(name, "z3 is negative")
+	}
+
+	// Fig 14. Range Check
+	twoLEps := new(big.Int).Lsh(one, uint(params.L+params.Epsilon))
+	if !prime.IsInInterval(pf.Z1, twoLEps) {
+		return abort.NewRejectedError(name, "range check", "z1 is not in [0, 2^(l+eps))")
+	}
+
+	e := challenge(params, pk, NCap, s, t, K, pf.S, pf.A, pf.C, cmt.OptionalSession(optionalSession))
+
+	// Fig 14. Equality Check
+	{
+		modN0Squared := prime.ModInt(N0Squared)
+		LHS := modN0Squared.Mul(modN0Squared.Exp(pk.Gamma(), pf.Z1), modN0Squared.Exp(pf.Z2, N0))
+		RHS := modN0Squared.Mul(pf.A, modN0Squared.Exp(K, e))
+		if LHS.Cmp(RHS) != 0 {
+			return abort.NewRejectedError(name, "equality check 1", "(1+N0)^z1 * z2^N0 != A * K^e mod N0^2")
+		}
+	}
+
+	{
+		modNCap := prime.ModInt(NCap)
+		LHS := modNCap.Mul(modNCap.Exp(s, pf.Z1), modNCap.Exp(t, pf.Z3))
+		RHS := modNCap.Mul(pf.C, modNCap.Exp(pf.S, e))
+		if LHS.Cmp(RHS) != 0 {
+			return abort.NewRejectedError(name, "equality check 2", "s^z1 * t^z3 != C * S^e mod NCap")
+		}
+	}
+	return nil
+}
+
+func (pf *ProofEnc) ValidateBasic() bool {
+	return pf.S != nil &&
+		pf.A != nil &&
+		pf.C != nil &&
+		pf.Z1 != nil &&
+		pf.Z2 != nil &&
+		pf.Z3 != nil
+}
+
+func (pf *ProofEnc) Bytes() [ProofEncBytesParts][]byte {
+	return [...][]byte{
+		pf.S.Bytes(),
+		pf.A.Bytes(),
+		pf.C.Bytes(),
+		pf.Z1.Bytes(),
+		pf.Z2.Bytes(),
+		pf.Z3.Bytes(),
+	}
+}
+
+// ----- utils
+
+// challenge derives e, binding the range bounds along with the statement
+func challenge(params Params, pk *paillier.PublicKey, NCap, s, t, K, S, A, C *big.Int, session *cmt.Session) *big.Int {
+	L, eps := big.NewInt(int64(params.L)), big.NewInt(int64(params.Epsilon))
+	ch := &cmt.Challenge{
+		Domain:     "zk-proof/encproof/v1",
+		Statement:  append(pk.AsInts(), NCap, s, t, K, L, eps),
+		Commitment: []*big.Int{S, A, C},
+		Legacy:     append(pk.AsInts(), NCap, s, t, K, S, A, C, L, eps),
+	}
+	return ch.Bits(session, ChallengeBits)
+}
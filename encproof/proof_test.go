@@ -0,0 +1,141 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package encproof
+
+import (
+	"context"
+	"errors"
+	"github.com/zhp12543/zk-proof/abort"
+	"github.com/zhp12543/zk-proof/cmt"
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/paillier"
+	"github.com/zhp12543/zk-proof/prime"
+	"math/big"
+	"testing"
+	"time"
+)
+
+const (
+	testPaillierKeyLength = 1024
+	testSafePrimeBitLen   = 512
+)
+
+func setUp(t *testing.T) (pk *paillier.PublicKey, NCap, s, tt *big.Int) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	_, pk, err := paillier.GenerateKeyPair(ctx, testPaillierKeyLength)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sgps, err := prime.GetRandomSafePrimesConcurrent(ctx, testSafePrimeBitLen, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	NCap = new(big.Int).Mul(sgps[0].SafePrime(), sgps[1].SafePrime())
+	f := curve.GetRandomPositiveRelativelyPrimeInt(NCap)
+	tt = prime.ModInt(NCap).Mul(f, f)
+	s = prime.ModInt(NCap).Exp(tt, curve.GetRandomPositiveRelativelyPrimeInt(NCap))
+	return
+}
+
+func TestProofEnc(t *testing.T) {
+	pk, NCap, s, tt := setUp(t)
+	params := Params{L: 64, Epsilon: 384}
+	k := curve.MustGetRandomInt(params.L)
+	K, rho, err := pk.EncryptAndReturnRandomness(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pf, err := NewProof(params, pk, NCap, s, tt, K, k, rho)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pf.Verify(params, pk, NCap, s, tt, K) {
+		t.Fatal("ProofEnc did not verify")
+	}
+	bzs := pf.Bytes()
+	pf2, err := NewProofFromBytes(bzs[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pf2.Verify(params, pk, NCap, s, tt, K) {
+		t.Fatal("ProofEnc did not verify after a bytes round trip")
+	}
+	pf3, err := ProofEncUnFlat(pf.Flat())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pf3.Verify(params, pk, NCap, s, tt, K) {
+		t.Fatal("ProofEnc did not verify after a flat round trip")
+	}
+	if pf.Verify(Params{L: 64, Epsilon: 400}, pk, NCap, s, tt, K) {
+		t.Error("ProofEnc verified under different params")
+	}
+	otherK, err := pk.Encrypt(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pf.Verify(params, pk, NCap, s, tt, otherK) {
+		t.Error("ProofEnc verified for another ciphertext")
+	}
+
+	bad := *pf
+	bad.Z3 = big.NewInt(-1)
+	if err := bad.VerifyWithReason(params, pk, NCap, s, tt, K); !abort.IsMalformed(err) {
+		t.Errorf("expected a negative z3 to be malformed, got %v", err)
+	}
+	bad = *pf
+	bad.Z2 = new(big.Int).Add(pf.Z2, one)
+	if err := bad.VerifyWithReason(params, pk, NCap, s, tt, K); !abort.IsRejected(err) {
+		t.Errorf("expected a tampered z2 to be rejected, got %v", err)
+	}
+
+	session, _ := cmt.NewSession([]byte("session"), big.NewInt(1))
+	other, _ := cmt.NewSession([]byte("session"), big.NewInt(2))
+	pf, err = NewProof(params, pk, NCap, s, tt, K, k, rho, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pf.Verify(params, pk, NCap, s, tt, K, session) {
+		t.Fatal("ProofEnc did not verify in its session")
+	}
+	if pf.Verify(params, pk, NCap, s, tt, K, other) || pf.Verify(params, pk, NCap, s, tt, K) {
+		t.Error("ProofEnc verified outside its session")
+	}
+}
+
+func TestProofEncOutOfRange(t *testing.T) {
+	pk, NCap, s, tt := setUp(t)
+	params := Params{L: 64, Epsilon: 300}
+	k := new(big.Int).Lsh(one, 700) // beyond 2^(ℓ+ε)
+	K, rho, err := pk.EncryptAndReturnRandomness(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewProof(params, pk, NCap, s, tt, K, k, rho); err == nil {
+		t.Fatal("expected an error for k >= 2^ℓ")
+	}
+	// an honest proof for a wider range does not pass the narrow range check
+	wide := Params{L: 701, Epsilon: 300}
+	pf, err := NewProof(wide, pk, NCap, s, tt, K, k, rho)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pf.Verify(wide, pk, NCap, s, tt, K) {
+		t.Fatal("ProofEnc did not verify for the wide range")
+	}
+	if pf.Verify(params, pk, NCap, s, tt, K) {
+		t.Error("ProofEnc verified for k outside of the range")
+	}
+	var ve *abort.VerifyError
+	if err := pf.VerifyWithReason(params, pk, NCap, s, tt, K); !errors.As(err, &ve) || ve.Step != "range check" {
+		t.Errorf("expected the range check to fail, got %v", err)
+	}
+	if err := (Params{L: 64, Epsilon: ChallengeBits}).Validate(); err == nil {
+		t.Error("expected an error for ε <= ChallengeBits")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"crypto/elliptic"
 	"errors"
 	"fmt"
+	"github.com/zhp12543/zk-proof/abort"
 	"github.com/zhp12543/zk-proof/cmt"
 	"github.com/zhp12543/zk-proof/curve"
 	"github.com/zhp12543/zk-proof/prime"
@@ -32,8 +33,8 @@ var (
 	one            = big.NewInt(1)
 )
 
-// NewProof implements proofFac
-func NewProof(ec elliptic.Curve, N0, NCap, s, t, N0p, N0q *big.Int) (*ProofFac, error) {
+// NewProof implements proofFac.
+func NewProof(ec elliptic.Curve, N0, NCap, s, t, N0p, N0q *big.Int, optionalSession ...*cmt.Session) (*ProofFac, error) {
 	if ec == nil || N0 == nil || NCap == nil || s == nil || t == nil || N0p == nil || N0q == nil {
 		return nil, errors.New("ProveFac constructor received nil value(s)")
 	}
@@ -76,11 +77,7 @@ func NewProof(ec elliptic.Curve, N0, NCap, s, t, N0p, N0q *big.Int) (*ProofFac,
 	T = modNCap.Mul(T, modNCap.Exp(t, r))
 
 	// Fig 28.2 e
-	var e *big.Int
-	{
-		eHash := cmt.SHA512_256i(N0, NCap, s, t, P, Q, A, B, T, sigma)
-		e = cmt.RejectionSample(q, eHash)
-	}
+	e := facChallenge(q, N0, NCap, s, t, []*big.Int{P, Q, A, B, T, sigma}, cmt.OptionalSession(optionalSession))
 
 	// Fig 28.3
 	z1 := new(big.Int).Mul(e, N0p)
@@ -122,15 +119,21 @@ func NewProofFromBytes(bzs [][]byte) (*ProofFac, error) {
 	}, nil
 }
 
-func (pf *ProofFac) Verify(ec elliptic.Curve, N0, NCap, s, t *big.Int) bool {
+func (pf *ProofFac) Verify(ec elliptic.Curve, N0, NCap, s, t *big.Int, optionalSession ...*cmt.Session) bool {
+	return pf.VerifyWithReason(ec, N0, NCap, s, t, optionalSession...) == nil
+}
+
+// VerifyWithReason is Verify returning an *abort.VerifyError that names the failed check of CGGMP21 Fig. 28
+func (pf *ProofFac) VerifyWithReason(ec elliptic.Curve, N0, NCap, s, t *big.Int, optionalSession ...*cmt.Session) error {
+	const name = "ProofFac"
 	if pf == nil || !pf.ValidateBasic() || ec == nil || N0 == nil || NCap == nil || s == nil || t == nil {
-		return false
+		return abort.NewMalformedError(name, "nil proof or statement value(s)")
 	}
 	if N0.Sign() != 1 {
-		return false
+		return abort.NewMalformedError(name, "N0 is not positive")
 	}
 	if NCap.Sign() != 1 {
-		return false
+		return abort.NewMalformedError(name, "NCap is not positive")
 	}
 
 	q := ec.Params().N
@@ -144,62 +147,58 @@ func (pf *ProofFac) Verify(ec elliptic.Curve, N0, NCap, s, t *big.Int) bool {
 	leNCap2 := new(big.Int).Lsh(new(big.Int).Mul(lNCap, q), 1)
 
 	if !prime.IsInInterval(pf.P, NCap) {
-		return false
+		return abort.NewMalformedError(name, "P is not in [0, NCap)")
 	}
 	if !prime.IsInInterval(pf.Q, NCap) {
-		return false
+		return abort.NewMalformedError(name, "Q is not in [0, NCap)")
 	}
 	if !prime.IsInInterval(pf.A, NCap) {
-		return false
+		return abort.NewMalformedError(name, "A is not in [0, NCap)")
 	}
 	if !prime.IsInInterval(pf.B, NCap) {
-		return false
+		return abort.NewMalformedError(name, "B is not in [0, NCap)")
 	}
 	if !prime.IsInInterval(pf.T, NCap) {
-		return false
+		return abort.NewMalformedError(name, "T is not in [0, NCap)")
 	}
 	if !prime.IsInInterval(pf.Sigma, lN0NCap) {
-		return false
+		return abort.NewMalformedError(name, "sigma is not in [0, l * N0 * NCap)")
 	}
 	if new(big.Int).GCD(nil, nil, pf.P, NCap).Cmp(one) != 0 {
-		return false
+		return abort.NewMalformedError(name, "P is not coprime to NCap")
 	}
 	if new(big.Int).GCD(nil, nil, pf.Q, NCap).Cmp(one) != 0 {
-		return false
+		return abort.NewMalformedError(name, "Q is not coprime to NCap")
 	}
 	if new(big.Int).GCD(nil, nil, pf.A, NCap).Cmp(one) != 0 {
-		return false
+		return abort.NewMalformedError(name, "A is not coprime to NCap")
 	}
 	if new(big.Int).GCD(nil, nil, pf.B, NCap).Cmp(one) != 0 {
-		return false
+		return abort.NewMalformedError(name, "B is not coprime to NCap")
 	}
 	if new(big.Int).GCD(nil, nil, pf.T, NCap).Cmp(one) != 0 {
-		return false
+		return abort.NewMalformedError(name, "T is not coprime to NCap")
 	}
 	if !prime.IsInInterval(pf.W1, leNCap2) {
-		return false
+		return abort.NewMalformedError(name, "w1 is out of range")
 	}
 	if !prime.IsInInterval(pf.W2, leNCap2) {
-		return false
+		return abort.NewMalformedError(name, "w2 is out of range")
 	}
 	if !prime.IsInInterval(pf.V, leN0NCap2) {
-		return false
+		return abort.NewMalformedError(name, "v is out of range")
 	}
 
 	// Fig 28. Range Check
 	if !prime.IsInInterval(pf.Z1, leSqrtN0) {
-		return false
+		return abort.NewRejectedError(name, "range check", "z1 is not in [0, sqrt(N0) * 2^(l+e))")
 	}
 
 	if !prime.IsInInterval(pf.Z2, leSqrtN0) {
-		return false
+		return abort.NewRejectedError(name, "range check", "z2 is not in [0, sqrt(N0) * 2^(l+e))")
 	}
 
-	var e *big.Int
-	{
-		eHash := cmt.SHA512_256i(N0, NCap, s, t, pf.P, pf.Q, pf.A, pf.B, pf.T, pf.Sigma)
-		e = cmt.RejectionSample(q, eHash)
-	}
+	e := facChallenge(q, N0, NCap, s, t, []*big.Int{pf.P, pf.Q, pf.A, pf.B, pf.T, pf.Sigma}, cmt.OptionalSession(optionalSession))
 
 	// Fig 28. Equality Check
 	modNCap := prime.ModInt(NCap)
@@ -208,7 +207,7 @@ func (pf *ProofFac) Verify(ec elliptic.Curve, N0, NCap, s, t *big.Int) bool {
 		RHS := modNCap.Mul(pf.A, modNCap.Exp(pf.P, e))
 
 		if LHS.Cmp(RHS) != 0 {
-			return false
+			return abort.NewRejectedError(name, "equality check 1", "s^z1 * t^w1 != A * P^e mod NCap")
 		}
 	}
 
@@ -217,7 +216,7 @@ func (pf *ProofFac) Verify(ec elliptic.Curve, N0, NCap, s, t *big.Int) bool {
 		RHS := modNCap.Mul(pf.B, modNCap.Exp(pf.Q, e))
 
 		if LHS.Cmp(RHS) != 0 {
-			return false
+			return abort.NewRejectedError(name, "equality check 2", "s^z2 * t^w2 != B * Q^e mod NCap")
 		}
 	}
 
@@ -227,11 +226,11 @@ func (pf *ProofFac) Verify(ec elliptic.Curve, N0, NCap, s, t *big.Int) bool {
 		RHS := modNCap.Mul(pf.T, modNCap.Exp(R, e))
 
 		if LHS.Cmp(RHS) != 0 {
-			return false
+			return abort.NewRejectedError(name, "equality check 3", "Q^z1 * t^v != T * R^e mod NCap")
 		}
 	}
 
-	return true
+	return nil
 }
 
 func (pf *ProofFac) ValidateBasic() bool {
@@ -263,3 +262,15 @@ func (pf *ProofFac) Bytes() [ProofFacBytesParts][]byte {
 		pf.V.Bytes(),
 	}
 }
+
+// ----- utils
+
+// facChallenge derives e. `commitments` are P, Q, A, B, T and sigma.
+func facChallenge(q, N0, NCap, s, t *big.Int, commitments []*big.Int, session *cmt.Session) *big.Int {
+	ch := &cmt.Challenge{
+		Domain:     "zk-proof/facproof/v1",
+		Statement:  []*big.Int{N0, NCap, s, t},
+		Commitment: commitments,
+	}
+	return ch.Scalar(session, q)
+}
@@ -0,0 +1,92 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package frost
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"github.com/decred/dcrd/dcrec/edwards"
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/vss"
+	"math/big"
+)
+
+type (
+	// KeyShare is one party's Shamir share of an Ed25519 secret key. Every slice is indexed by party position in Ks.
+	KeyShare struct {
+		Threshold   int        // t; any t+1 parties can sign
+		Ks          []*big.Int // the Shamir index of every party
+		ShareID, Xi *big.Int
+		BigXj       []*curve.ECPoint // X_j = g^x_j
+		EDDSAPub    *curve.ECPoint
+	}
+)
+
+// Deal acts as a trusted dealer: it samples a secret key mod the edwards order and splits it into shares for the given indexes.
+// The shares of a Feldman VSS based distributed keygen over the edwards curve can be used in the same way.
+func Deal(threshold int, ids []*big.Int) ([]*KeyShare, error) {
+	ec := edwards.Edwards()
+	x := curve.GetRandomPositiveInt(ec.Params().N)
+	vs, shares, err := vss.Create(ec, threshold, x, ids)
+	if err != nil {
+		return nil, err
+	}
+	bigXj := make([]*curve.ECPoint, len(ids))
+	for j, id := range ids {
+		if bigXj[j], err = vs.EvaluateAt(ec, id); err != nil {
+			return nil, err
+		}
+	}
+	out := make([]*KeyShare, len(ids))
+	for i, share := range shares {
+		out[i] = &KeyShare{
+			Threshold: threshold,
+			Ks:        ids,
+			ShareID:   share.ID,
+			Xi:        share.Share,
+			BigXj:     bigXj,
+			EDDSAPub:  vs[0],
+		}
+	}
+	return out, nil
+}
+
+// PublicKey returns the group public key in the encoding of crypto/ed25519
+func (ks *KeyShare) PublicKey() ed25519.PublicKey {
+	return encodePoint(ks.EDDSAPub)
+}
+
+// ValidateBasic checks that the share is consistent with X_i
+func (ks *KeyShare) ValidateBasic() error {
+	if ks == nil || ks.ShareID == nil || ks.Xi == nil || !ks.EDDSAPub.ValidateBasic() || len(ks.Ks) != len(ks.BigXj) {
+		return errors.New("frost: malformed key share")
+	}
+	i := indexOf(ks.Ks, ks.ShareID)
+	if i < 0 {
+		return errors.New("frost: the share ID is not in Ks")
+	}
+	if !curve.ScalarBaseMult(edwards.Edwards(), ks.Xi).Equals(ks.BigXj[i]) {
+		return fmt.Errorf("frost: x_i does not match X_%d", i)
+	}
+	return nil
+}
+
+// ----- utils
+
+func encodePoint(p *curve.ECPoint) []byte {
+	return edwards.BigIntPointToEncodedBytes(p.X(), p.Y())[:]
+}
+
+func indexOf(ids []*big.Int, id *big.Int) int {
+	for i, v := range ids {
+		if v != nil && v.Cmp(id) == 0 {
+			return i
+		}
+	}
+	return -1
+}
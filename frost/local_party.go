@@ -0,0 +1,190 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+// Package frost implements two-round threshold Ed25519 signing following FROST (Komlo, C., Goldberg, I.:
+// FROST: Flexible Round-Optimized Schnorr Threshold Signatures, SAC 2020) over the edwards curve in the curve package.
+// The signatures are plain Ed25519 signatures and verify with crypto/ed25519.
+//
+// Usage: call Round1 and broadcast its message, then pass every message received from the other signers to Update
+// and broadcast whatever it returns, until Outputs reports that the signature is ready.
+// A LocalParty must not be reused: its nonces are single-use.
+
+package frost
+
+import (
+	"errors"
+	"fmt"
+	"github.com/decred/dcrd/dcrec/edwards"
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/vss"
+	"math/big"
+)
+
+type (
+	Parameters struct {
+		PartyIDs  []*big.Int // the Shamir indexes of the signers, in the same order for all signers
+		Index     int        // our position in PartyIDs
+		Threshold int        // t; at least t+1 signers take part
+	}
+
+	LocalParty struct {
+		params *Parameters
+		key    *KeyShare
+		msg    []byte
+		round  int // the last round started, 0 before Round1
+		temp   localTempData
+		data   SignatureData
+		done   bool
+	}
+
+	// SignatureData holds the 64 byte Ed25519 signature R || S of M
+	SignatureData struct {
+		R         *curve.ECPoint
+		S         *big.Int
+		Signature []byte
+		M         []byte
+	}
+
+	localTempData struct {
+		keyIdx       []int // position of each signer in key.Ks
+		di, ei       *big.Int
+		bigDs, bigEs []*curve.ECPoint // per signer
+		rhos         []*big.Int       // binding factors, per signer
+		bigR         *curve.ECPoint
+		c            *big.Int
+		r1msgs       []*SignRound1Message
+		r2msgs       []*SignRound2Message
+	}
+)
+
+func NewParameters(partyIDs []*big.Int, index, threshold int) (*Parameters, error) {
+	if threshold < 1 || len(partyIDs) <= threshold {
+		return nil, fmt.Errorf("frost: expected at least %d signers but got %d", threshold+1, len(partyIDs))
+	}
+	if index < 0 || len(partyIDs) <= index {
+		return nil, fmt.Errorf("frost: party index %d out of range", index)
+	}
+	if err := vss.CheckIndexes(edwards.Edwards(), partyIDs); err != nil {
+		return nil, err
+	}
+	return &Parameters{PartyIDs: partyIDs, Index: index, Threshold: threshold}, nil
+}
+
+func (params *Parameters) PartyCount() int {
+	return len(params.PartyIDs)
+}
+
+// NewLocalParty creates the state machine of one signer of msg
+func NewLocalParty(params *Parameters, key *KeyShare, msg []byte) (*LocalParty, error) {
+	if params == nil || msg == nil {
+		return nil, errors.New("frost: NewLocalParty received nil params")
+	}
+	if err := key.ValidateBasic(); err != nil {
+		return nil, err
+	}
+	if key.Threshold != params.Threshold {
+		return nil, fmt.Errorf("frost: the key was generated for threshold %d", key.Threshold)
+	}
+	if key.ShareID.Cmp(params.PartyIDs[params.Index]) != 0 {
+		return nil, errors.New("frost: the key does not belong to our party ID")
+	}
+	n := params.PartyCount()
+	p := &LocalParty{params: params, key: key, msg: msg}
+	p.temp.keyIdx = make([]int, n)
+	for j, id := range params.PartyIDs {
+		if p.temp.keyIdx[j] = indexOf(key.Ks, id); p.temp.keyIdx[j] < 0 {
+			return nil, fmt.Errorf("frost: signer %d is not a party of the key", j)
+		}
+	}
+	p.temp.bigDs = make([]*curve.ECPoint, n)
+	p.temp.bigEs = make([]*curve.ECPoint, n)
+	p.temp.r1msgs = make([]*SignRound1Message, n)
+	p.temp.r2msgs = make([]*SignRound2Message, n)
+	return p, nil
+}
+
+// Update stores a message from another signer. Once every message of the current round has arrived it runs the
+// next round and returns that round's outgoing messages, which may be none.
+func (p *LocalParty) Update(msg *Message) ([]*Message, error) {
+	if err := p.storeMessage(msg); err != nil {
+		return nil, err
+	}
+	var out []*Message
+	for !p.done && p.canProceed() {
+		var msgs []*Message
+		var err error
+		switch p.round {
+		case 1:
+			msgs, err = p.Round2()
+		case 2:
+			err = p.finish()
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, msgs...)
+	}
+	return out, nil
+}
+
+// Outputs returns the signature once signing has finished
+func (p *LocalParty) Outputs() (*SignatureData, bool) {
+	if !p.done {
+		return nil, false
+	}
+	return &p.data, true
+}
+
+func (p *LocalParty) storeMessage(msg *Message) error {
+	if msg == nil || msg.Content == nil || !msg.Content.ValidateBasic() {
+		return errors.New("frost: received a malformed message")
+	}
+	from := msg.From
+	if from < 0 || p.params.PartyCount() <= from || from == p.params.Index {
+		return fmt.Errorf("frost: received a message from invalid party %d", from)
+	}
+	if msg.To != Broadcast {
+		return fmt.Errorf("frost: received a misrouted message from party %d", from)
+	}
+	dup := fmt.Errorf("frost: received a duplicate round %d message from party %d", msg.Content.RoundNumber(), from)
+	switch content := msg.Content.(type) {
+	case *SignRound1Message:
+		if p.temp.r1msgs[from] != nil {
+			return dup
+		}
+		p.temp.r1msgs[from] = content
+	case *SignRound2Message:
+		if p.temp.r2msgs[from] != nil {
+			return dup
+		}
+		p.temp.r2msgs[from] = content
+	default:
+		return fmt.Errorf("frost: received an unknown message type %T from party %d", content, from)
+	}
+	return nil
+}
+
+// canProceed reports whether every other signer's message for the current round has arrived
+func (p *LocalParty) canProceed() bool {
+	for j := 0; j < p.params.PartyCount(); j++ {
+		if j == p.params.Index {
+			continue
+		}
+		switch p.round {
+		case 1:
+			if p.temp.r1msgs[j] == nil {
+				return false
+			}
+		case 2:
+			if p.temp.r2msgs[j] == nil {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package frost
+
+import (
+	"crypto/ed25519"
+	"github.com/zhp12543/zk-proof/test"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+const (
+	testParties   = 3
+	testThreshold = 1
+)
+
+func testKeys(t *testing.T) []*KeyShare {
+	ids := make([]*big.Int, testParties)
+	for i := range ids {
+		ids[i] = big.NewInt(int64(i + 1))
+	}
+	keys, err := Deal(testThreshold, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+// runSigning signs msg with the given keys, letting tamper modify each message before delivery
+func runSigning(keys []*KeyShare, msg []byte, tamper func(*Message)) ([]*SignatureData, error) {
+	ids := make([]*big.Int, len(keys))
+	for i, key := range keys {
+		ids[i] = key.ShareID
+	}
+	parties := make([]*LocalParty, len(keys))
+	var queue []*Message
+	for i, key := range keys {
+		params, err := NewParameters(ids, i, testThreshold)
+		if err != nil {
+			return nil, err
+		}
+		if parties[i], err = NewLocalParty(params, key, msg); err != nil {
+			return nil, err
+		}
+		out, err := parties[i].Round1()
+		if err != nil {
+			return nil, err
+		}
+		queue = append(queue, out...)
+	}
+	err := test.Route(queue, func(m *Message) []int {
+		if tamper != nil {
+			tamper(m)
+		}
+		return test.Recipients(len(parties), m.From, m.To)
+	}, func(j int, m *Message) ([]*Message, error) {
+		return parties[j].Update(m)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sigs := make([]*SignatureData, len(parties))
+	for i, party := range parties {
+		sigs[i], _ = party.Outputs()
+	}
+	return sigs, nil
+}
+
+func TestE2E(t *testing.T) {
+	keys := testKeys(t)
+	msg := []byte("threshold ed25519")
+	for _, signers := range [][]int{{0, 1}, {2, 0}, {0, 1, 2}} {
+		subset := make([]*KeyShare, len(signers))
+		for i, s := range signers {
+			subset[i] = keys[s]
+		}
+		sigs, err := runSigning(subset, msg, nil)
+		if err != nil {
+			t.Fatalf("signers %v: %v", signers, err)
+		}
+		for i, sig := range sigs {
+			if sig == nil {
+				t.Fatalf("signers %v: signer %d did not finish", signers, i)
+			}
+			if !ed25519.Verify(keys[0].PublicKey(), msg, sig.Signature) {
+				t.Errorf("signers %v: signature of signer %d does not verify", signers, i)
+			}
+		}
+		if string(sigs[0].Signature) != string(sigs[1].Signature) {
+			t.Errorf("signers %v: signers disagree on the signature", signers)
+		}
+	}
+}
+
+func TestBadShareIdentifiesCulprit(t *testing.T) {
+	keys := testKeys(t)
+	_, err := runSigning(keys[:2], []byte("msg"), func(m *Message) {
+		if content, ok := m.Content.(*SignRound2Message); ok && m.From == 1 {
+			content.Zi = new(big.Int).Add(content.Zi, big.NewInt(1))
+		}
+	})
+	if err == nil || !strings.Contains(err.Error(), "party 1") {
+		t.Fatalf("expected party 1 to be blamed, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package frost
+
+import (
+	"math/big"
+)
+
+const (
+	// Broadcast is the Message.To value of a message sent to every other signer
+	Broadcast = -1
+)
+
+type (
+	// Message is broadcast by the caller from signer `From` to every other signer
+	Message struct {
+		From, To int // positions in Parameters.PartyIDs
+		Content  MessageContent
+	}
+
+	MessageContent interface {
+		RoundNumber() int
+		ValidateBasic() bool
+	}
+
+	// SignRound1Message publishes the nonce commitments D_i = g^d_i and E_i = g^e_i, flattened
+	SignRound1Message struct {
+		Commitments []*big.Int
+	}
+
+	// SignRound2Message publishes the signature share z_i
+	SignRound2Message struct {
+		Zi *big.Int
+	}
+)
+
+func (m *SignRound1Message) RoundNumber() int { return 1 }
+
+func (m *SignRound1Message) ValidateBasic() bool {
+	if m == nil || len(m.Commitments) != 4 {
+		return false
+	}
+	for _, v := range m.Commitments {
+		if v == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *SignRound2Message) RoundNumber() int { return 2 }
+
+func (m *SignRound2Message) ValidateBasic() bool {
+	return m != nil && m.Zi != nil
+}
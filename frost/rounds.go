@@ -0,0 +1,189 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package frost
+
+import (
+	"crypto/ed25519"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"github.com/decred/dcrd/dcrec/edwards"
+	"github.com/zhp12543/zk-proof/cmt"
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/vss"
+	"math/big"
+)
+
+var (
+	// bindingDomain separates the binding factors from the other values hashed with cmt.SHA512_256i
+	bindingDomain = new(big.Int).SetBytes([]byte("zk-proof/frost/binding/v1"))
+)
+
+// Round1 samples the nonces d_i, e_i and broadcasts D_i = g^d_i and E_i = g^e_i
+func (p *LocalParty) Round1() ([]*Message, error) {
+	if p.round != 0 {
+		return nil, errors.New("frost: Round1 has already been run")
+	}
+	p.round = 1
+	ec, i := edwards.Edwards(), p.params.Index
+	q := ec.Params().N
+
+	p.temp.di, p.temp.ei = randomNonZero(q), randomNonZero(q)
+	p.temp.bigDs[i] = curve.ScalarBaseMult(ec, p.temp.di)
+	p.temp.bigEs[i] = curve.ScalarBaseMult(ec, p.temp.ei)
+	flat, err := curve.FlattenECPoints([]*curve.ECPoint{p.temp.bigDs[i], p.temp.bigEs[i]})
+	if err != nil {
+		return nil, err
+	}
+	return []*Message{{From: i, To: Broadcast, Content: &SignRound1Message{Commitments: flat}}}, nil
+}
+
+// Round2 derives every signer's binding factor rho_j and the group commitment R = prod D_j * E_j^rho_j,
+// then broadcasts our share z_i = d_i + e_i * rho_i + lambda_i * x_i * c of the signature
+func (p *LocalParty) Round2() ([]*Message, error) {
+	if p.round != 1 || !p.canProceed() {
+		return nil, errors.New("frost: Round2 is not ready")
+	}
+	p.round = 2
+	ec, i := edwards.Edwards(), p.params.Index
+	q := ec.Params().N
+
+	for j, msg := range p.temp.r1msgs {
+		if j == i {
+			continue
+		}
+		points, err := curve.UnFlattenECPoints(ec, msg.Commitments)
+		if err != nil || points[0].IsIdentity() || points[1].IsIdentity() {
+			return nil, fmt.Errorf("frost round 2: invalid nonce commitments from party %d", j)
+		}
+		p.temp.bigDs[j], p.temp.bigEs[j] = points[0], points[1]
+	}
+
+	p.temp.rhos = p.bindingFactors()
+	var bigR *curve.ECPoint
+	for j := range p.params.PartyIDs {
+		Rj, err := p.temp.bigEs[j].ScalarMult(p.temp.rhos[j]).Add(p.temp.bigDs[j])
+		if err != nil {
+			return nil, err
+		}
+		if bigR == nil {
+			bigR = Rj
+		} else if bigR, err = bigR.Add(Rj); err != nil {
+			return nil, err
+		}
+	}
+	p.temp.bigR = bigR
+	p.temp.c = challenge(bigR, p.key.EDDSAPub, p.msg)
+
+	// z_i = d_i + e_i * rho_i + lambda_i * x_i * c
+	lambda := vss.LagrangeCoefficient(ec, p.params.PartyIDs, i)
+	zi := new(big.Int).Mul(p.temp.ei, p.temp.rhos[i])
+	zi = zi.Add(zi, p.temp.di)
+	lxc := new(big.Int).Mul(lambda, p.key.Xi)
+	lxc = lxc.Mul(lxc, p.temp.c)
+	zi = zi.Add(zi, lxc)
+	zi = zi.Mod(zi, q)
+	// the nonces must never be used again
+	p.temp.di, p.temp.ei = nil, nil
+	p.temp.r2msgs[i] = &SignRound2Message{Zi: zi}
+	return []*Message{{From: i, To: Broadcast, Content: p.temp.r2msgs[i]}}, nil
+}
+
+// finish checks every signature share g^z_j = D_j * E_j^rho_j * X_j^(c * lambda_j) and sums them to z.
+// A share that fails the check identifies its sender.
+func (p *LocalParty) finish() error {
+	if p.round != 2 || !p.canProceed() {
+		return errors.New("frost: finish is not ready")
+	}
+	ec, i := edwards.Edwards(), p.params.Index
+	q := ec.Params().N
+
+	z := new(big.Int)
+	for j, msg := range p.temp.r2msgs {
+		if j == i {
+			continue
+		}
+		zj := msg.Zi
+		if zj.Sign() == -1 || zj.Cmp(q) != -1 {
+			return fmt.Errorf("frost finish: signature share of party %d is out of range", j)
+		}
+		lambda := vss.LagrangeCoefficient(ec, p.params.PartyIDs, j)
+		cl := new(big.Int).Mul(p.temp.c, lambda)
+		cl = cl.Mod(cl, q)
+		right, err := p.temp.bigEs[j].ScalarMult(p.temp.rhos[j]).Add(p.temp.bigDs[j])
+		if err != nil {
+			return err
+		}
+		if right, err = right.Add(p.key.BigXj[p.temp.keyIdx[j]].ScalarMult(cl)); err != nil {
+			return err
+		}
+		if !curve.ScalarBaseMult(ec, zj).Equals(right) {
+			return fmt.Errorf("frost finish: signature share of party %d failed verification", j)
+		}
+		z = z.Add(z, zj)
+	}
+	z = z.Add(z, p.ourShare())
+	z = z.Mod(z, q)
+
+	sig := make([]byte, 0, ed25519.SignatureSize)
+	sig = append(sig, encodePoint(p.temp.bigR)...)
+	sig = append(sig, edwards.BigIntToEncodedBytes(z)[:]...)
+	if !ed25519.Verify(p.key.PublicKey(), p.msg, sig) {
+		return errors.New("frost finish: the aggregated signature failed verification")
+	}
+	p.data = SignatureData{R: p.temp.bigR, S: z, Signature: sig, M: p.msg}
+	p.done = true
+	return nil
+}
+
+// ----- utils
+
+// ourShare returns the z_i we broadcast in Round2, which is stored alongside the other signers' shares
+func (p *LocalParty) ourShare() *big.Int {
+	return p.temp.r2msgs[p.params.Index].Zi
+}
+
+// bindingFactors returns rho_j = H(j, Y, H(m), B) for every signer, where B lists every signer's index and commitments
+func (p *LocalParty) bindingFactors() []*big.Int {
+	q := edwards.Edwards().Params().N
+	mHash := new(big.Int).SetBytes(cmt.SHA512_256(p.msg))
+	B := make([]*big.Int, 0, 5*p.params.PartyCount())
+	for j, id := range p.params.PartyIDs {
+		D, E := p.temp.bigDs[j], p.temp.bigEs[j]
+		B = append(B, id, D.X(), D.Y(), E.X(), E.Y())
+	}
+	Y := p.key.EDDSAPub
+	rhos := make([]*big.Int, p.params.PartyCount())
+	for j, id := range p.params.PartyIDs {
+		in := append([]*big.Int{bindingDomain, id, Y.X(), Y.Y(), mHash}, B...)
+		rhos[j] = cmt.RejectionSample(q, cmt.SHA512_256i(in...))
+	}
+	return rhos
+}
+
+// challenge returns the Ed25519 challenge SHA-512(R || A || M) read as a little-endian integer mod the group order
+func challenge(R, Y *curve.ECPoint, msg []byte) *big.Int {
+	h := sha512.New()
+	h.Write(encodePoint(R))
+	h.Write(encodePoint(Y))
+	h.Write(msg)
+	digest := h.Sum(nil)
+	// reverse to big-endian
+	for l, r := 0, len(digest)-1; l < r; l, r = l+1, r-1 {
+		digest[l], digest[r] = digest[r], digest[l]
+	}
+	c := new(big.Int).SetBytes(digest)
+	return c.Mod(c, edwards.Edwards().Params().N)
+}
+
+func randomNonZero(q *big.Int) *big.Int {
+	for {
+		if k := curve.GetRandomPositiveInt(q); k.Sign() == 1 {
+			return k
+		}
+	}
+}
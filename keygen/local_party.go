@@ -0,0 +1,199 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+// Package keygen implements the GG18 distributed key generation (Gennaro, R., Goldfeder, S.: Fast Multiparty
+// Threshold ECDSA with Fast Trustless Setup, Fig. 6) as a message-driven state machine for one party.
+//
+// Besides the DLN proofs of GG18 the parties exchange the CGGMP21 Paillier-Blum modulus and no small factor
+// proofs, and Schnorr proofs of their VSS secrets and final shares.
+//
+// Usage: call Round1 and deliver its messages, then pass every message received from the other parties to Update
+// and deliver whatever it returns, until Outputs reports that the key share is ready.
+
+package keygen
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"github.com/zhp12543/zk-proof/cmt"
+	"github.com/zhp12543/zk-proof/proof"
+	"github.com/zhp12543/zk-proof/vss"
+	"math/big"
+)
+
+type (
+	Parameters struct {
+		EC        elliptic.Curve
+		PartyIDs  []*big.Int // the Shamir index of every party, in the same order for all parties
+		Index     int        // our position in PartyIDs
+		Threshold int        // t; any t+1 parties can sign
+		SessionID []byte     // unique per keygen and agreed on by all parties; binds every proof to this keygen
+	}
+
+	LocalParty struct {
+		params    *Parameters
+		preParams *proof.PaillierParams
+		round     int // the last round started, 0 before Round1
+		temp      localTempData
+		data      LocalPartySaveData
+		done      bool
+	}
+
+	localTempData struct {
+		ui       *big.Int
+		vs       vss.Vs
+		shares   vss.Shares
+		deCommit cmt.HashDeCommitment
+		// messages received from the other parties, indexed by sender
+		r1msgs  []*KGRound1Message
+		r2msgs1 []*KGRound2Message1
+		r2msgs2 []*KGRound2Message2
+		r3msgs  []*KGRound3Message
+	}
+)
+
+func NewParameters(ec elliptic.Curve, partyIDs []*big.Int, index, threshold int, sessionID []byte) (*Parameters, error) {
+	if ec == nil {
+		return nil, errors.New("keygen: nil curve")
+	}
+	if threshold < 1 || len(partyIDs) <= threshold {
+		return nil, fmt.Errorf("keygen: invalid threshold %d for %d parties", threshold, len(partyIDs))
+	}
+	if index < 0 || len(partyIDs) <= index {
+		return nil, fmt.Errorf("keygen: party index %d out of range", index)
+	}
+	if len(sessionID) == 0 {
+		return nil, errors.New("keygen: empty session ID")
+	}
+	if err := vss.CheckIndexes(ec, partyIDs); err != nil {
+		return nil, err
+	}
+	return &Parameters{EC: ec, PartyIDs: partyIDs, Index: index, Threshold: threshold, SessionID: sessionID}, nil
+}
+
+func (params *Parameters) PartyCount() int {
+	return len(params.PartyIDs)
+}
+
+// session binds the proofs made by party j to this keygen
+func (params *Parameters) session(j int) *cmt.Session {
+	return &cmt.Session{ID: params.SessionID, Party: params.PartyIDs[j]}
+}
+
+// NewLocalParty creates the state machine of one party. The pre-params must be generated out-of-band with proof.GeneratePreParams.
+func NewLocalParty(params *Parameters, preParams *proof.PaillierParams) (*LocalParty, error) {
+	if params == nil || preParams == nil || preParams.PaillierSK == nil {
+		return nil, errors.New("keygen: NewLocalParty received nil params")
+	}
+	n := params.PartyCount()
+	p := &LocalParty{params: params, preParams: preParams}
+	p.temp.r1msgs = make([]*KGRound1Message, n)
+	p.temp.r2msgs1 = make([]*KGRound2Message1, n)
+	p.temp.r2msgs2 = make([]*KGRound2Message2, n)
+	p.temp.r3msgs = make([]*KGRound3Message, n)
+	p.data = newLocalPartySaveData(n)
+	return p, nil
+}
+
+// Update stores a message from another party. Once every message of the current round has arrived it runs the
+// next round and returns that round's outgoing messages, which may be none.
+func (p *LocalParty) Update(msg *Message) ([]*Message, error) {
+	if err := p.storeMessage(msg); err != nil {
+		return nil, err
+	}
+	var out []*Message
+	for !p.done && p.canProceed() {
+		var msgs []*Message
+		var err error
+		switch p.round {
+		case 1:
+			msgs, err = p.Round2()
+		case 2:
+			msgs, err = p.Round3()
+		case 3:
+			err = p.finish()
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, msgs...)
+	}
+	return out, nil
+}
+
+// Outputs returns the saved key share once keygen has finished
+func (p *LocalParty) Outputs() (*LocalPartySaveData, bool) {
+	if !p.done {
+		return nil, false
+	}
+	return &p.data, true
+}
+
+func (p *LocalParty) storeMessage(msg *Message) error {
+	if msg == nil || msg.Content == nil || !msg.Content.ValidateBasic() {
+		return errors.New("keygen: received a malformed message")
+	}
+	from := msg.From
+	if from < 0 || p.params.PartyCount() <= from || from == p.params.Index {
+		return fmt.Errorf("keygen: received a message from invalid party %d", from)
+	}
+	if msg.Content.IsBroadcast() != (msg.To == Broadcast) || (msg.To != Broadcast && msg.To != p.params.Index) {
+		return fmt.Errorf("keygen: received a misrouted message from party %d", from)
+	}
+	dup := fmt.Errorf("keygen: received a duplicate round %d message from party %d", msg.Content.RoundNumber(), from)
+	switch content := msg.Content.(type) {
+	case *KGRound1Message:
+		if p.temp.r1msgs[from] != nil {
+			return dup
+		}
+		p.temp.r1msgs[from] = content
+	case *KGRound2Message1:
+		if p.temp.r2msgs1[from] != nil {
+			return dup
+		}
+		p.temp.r2msgs1[from] = content
+	case *KGRound2Message2:
+		if p.temp.r2msgs2[from] != nil {
+			return dup
+		}
+		p.temp.r2msgs2[from] = content
+	case *KGRound3Message:
+		if p.temp.r3msgs[from] != nil {
+			return dup
+		}
+		p.temp.r3msgs[from] = content
+	default:
+		return fmt.Errorf("keygen: received an unknown message type %T from party %d", content, from)
+	}
+	return nil
+}
+
+// canProceed reports whether every other party's messages for the current round have arrived
+func (p *LocalParty) canProceed() bool {
+	for j := 0; j < p.params.PartyCount(); j++ {
+		if j == p.params.Index {
+			continue
+		}
+		switch p.round {
+		case 1:
+			if p.temp.r1msgs[j] == nil {
+				return false
+			}
+		case 2:
+			if p.temp.r2msgs1[j] == nil || p.temp.r2msgs2[j] == nil {
+				return false
+			}
+		case 3:
+			if p.temp.r3msgs[j] == nil {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package keygen_test
+
+import (
+	"crypto/elliptic"
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/keygen"
+	"github.com/zhp12543/zk-proof/test"
+	"github.com/zhp12543/zk-proof/vss"
+	"math/big"
+	"testing"
+)
+
+const (
+	testParties   = 3
+	testThreshold = 1
+)
+
+func TestE2E(t *testing.T) {
+	ec := elliptic.P256()
+	keys, err := test.RunKeygen(ec, testParties, testThreshold)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shares := make(vss.Shares, testParties)
+	pub := keys[0].ECDSAPub
+	for i, data := range keys {
+		if !pub.Equals(data.ECDSAPub) {
+			t.Fatalf("party %d computed a different public key", i)
+		}
+		for j := range keys {
+			if !data.BigXj[j].Equals(keys[j].BigXj[j]) {
+				t.Fatalf("party %d disagrees on X_%d", i, j)
+			}
+		}
+		shares[i] = &vss.Share{Threshold: testThreshold, ID: data.ShareID, Share: data.Xi}
+	}
+
+	// any t+1 shares reconstruct the secret key of the public key
+	for _, subset := range []vss.Shares{shares[:2], shares[1:], {shares[0], shares[2]}} {
+		x, err := subset.ReConstruct(ec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !curve.ScalarBaseMult(ec, x).Equals(pub) {
+			t.Fatal("the reconstructed key does not match the public key")
+		}
+	}
+}
+
+func TestUpdateRejectsBadMessages(t *testing.T) {
+	ec := elliptic.P256()
+	preParams, err := test.LoadPreParams(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+	if _, err := keygen.NewParameters(ec, ids, 0, testThreshold, nil); err == nil {
+		t.Error("an empty session ID was accepted")
+	}
+	params, err := keygen.NewParameters(ec, ids, 0, testThreshold, []byte("keygen test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	party, err := keygen.NewLocalParty(params, preParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := party.Round1()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// pretend our own round 1 message came from party 1
+	msg := &keygen.Message{From: 1, To: keygen.Broadcast, Content: out[0].Content}
+	if _, err := party.Update(msg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := party.Update(msg); err == nil {
+		t.Error("a duplicate message was accepted")
+	}
+	if _, err := party.Update(&keygen.Message{From: 0, To: keygen.Broadcast, Content: out[0].Content}); err == nil {
+		t.Error("a message from ourselves was accepted")
+	}
+	if _, err := party.Update(&keygen.Message{From: 2, To: 1, Content: out[0].Content}); err == nil {
+		t.Error("a misrouted message was accepted")
+	}
+	if _, err := party.Update(&keygen.Message{From: 2, To: keygen.Broadcast, Content: &keygen.KGRound1Message{}}); err == nil {
+		t.Error("a malformed message was accepted")
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package keygen
+
+import (
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/facproof"
+	"github.com/zhp12543/zk-proof/paillier"
+	"github.com/zhp12543/zk-proof/schnorr"
+	"math/big"
+)
+
+const (
+	// Broadcast is the Message.To value of a message sent to every other party
+	Broadcast = -1
+)
+
+type (
+	// Message is routed by the caller from party `From` to party `To`, or to every other party if To is Broadcast
+	Message struct {
+		From, To int // positions in Parameters.PartyIDs
+		Content  MessageContent
+	}
+
+	MessageContent interface {
+		RoundNumber() int
+		IsBroadcast() bool
+		ValidateBasic() bool
+	}
+
+	// KGRound1Message commits to the party's VSS polynomial and publishes its Paillier and ring-Pedersen parameters
+	KGRound1Message struct {
+		Commitment *big.Int
+		PaillierN,
+		NTilde,
+		H1, H2 *big.Int
+		Dln1, Dln2 [][]byte
+	}
+
+	// KGRound2Message1 is sent point-to-point and carries the recipient's share along with
+	// a proof that the sender's Paillier modulus has no small factors, made against the recipient's NTilde
+	KGRound2Message1 struct {
+		Share    *big.Int
+		FacProof [][]byte
+	}
+
+	// KGRound2Message2 opens the round 1 commitment and proves knowledge of the secret behind it
+	KGRound2Message2 struct {
+		DeCommitment []*big.Int
+		ModProof     [][]byte
+		UProof       [][]byte // Schnorr proof for u_i = log_g(v_0)
+	}
+
+	// KGRound3Message proves knowledge of the final share x_i behind X_i = g^x_i
+	KGRound3Message struct {
+		XiProof [][]byte
+	}
+)
+
+func (m *KGRound1Message) RoundNumber() int  { return 1 }
+func (m *KGRound1Message) IsBroadcast() bool { return true }
+
+func (m *KGRound1Message) ValidateBasic() bool {
+	return m != nil &&
+		m.Commitment != nil &&
+		m.PaillierN != nil &&
+		m.NTilde != nil &&
+		m.H1 != nil &&
+		m.H2 != nil &&
+		len(m.Dln1) != 0 &&
+		len(m.Dln2) != 0
+}
+
+func (m *KGRound2Message1) RoundNumber() int  { return 2 }
+func (m *KGRound2Message1) IsBroadcast() bool { return false }
+
+func (m *KGRound2Message1) ValidateBasic() bool {
+	return m != nil &&
+		m.Share != nil &&
+		curve.NonEmptyMultiBytes(m.FacProof, facproof.ProofFacBytesParts)
+}
+
+func (m *KGRound2Message2) RoundNumber() int  { return 2 }
+func (m *KGRound2Message2) IsBroadcast() bool { return true }
+
+func (m *KGRound2Message2) ValidateBasic() bool {
+	if m == nil || len(m.DeCommitment) == 0 {
+		return false
+	}
+	for _, d := range m.DeCommitment {
+		if d == nil {
+			return false
+		}
+	}
+	return curve.NonEmptyMultiBytes(m.ModProof, paillier.ModProofBytesParts) &&
+		curve.NonEmptyMultiBytes(m.UProof, schnorr.ZKProofBytesParts)
+}
+
+func (m *KGRound3Message) RoundNumber() int  { return 3 }
+func (m *KGRound3Message) IsBroadcast() bool { return true }
+
+func (m *KGRound3Message) ValidateBasic() bool {
+	return m != nil && curve.NonEmptyMultiBytes(m.XiProof, schnorr.ZKProofBytesParts)
+}
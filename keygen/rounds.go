@@ -0,0 +1,265 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package keygen
+
+import (
+	"errors"
+	"fmt"
+	"github.com/zhp12543/zk-proof/cmt"
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/paillier"
+	"github.com/zhp12543/zk-proof/proof"
+	"github.com/zhp12543/zk-proof/schnorr"
+	"github.com/zhp12543/zk-proof/vss"
+	"math/big"
+)
+
+// Round1 samples u_i, shares it with Feldman VSS and broadcasts a commitment to the VSS commitments
+// together with our Paillier and ring-Pedersen parameters and their DLN proofs.
+func (p *LocalParty) Round1() ([]*Message, error) {
+	if p.round != 0 {
+		return nil, errors.New("keygen: Round1 has already been run")
+	}
+	p.round = 1
+	ec, i := p.params.EC, p.params.Index
+
+	// 1. calculate "partial" key share ui
+	ui := curve.GetRandomPositiveInt(ec.Params().N)
+
+	// 2. compute the vss shares
+	vs, shares, err := vss.Create(ec, p.params.Threshold, ui, p.params.PartyIDs)
+	if err != nil {
+		return nil, err
+	}
+	flatVs, err := vs.Flat()
+	if err != nil {
+		return nil, err
+	}
+	cmtDeCmt := cmt.NewHashCommitment(flatVs...)
+
+	// 3. DLN proofs that H1 and H2 generate the same group mod NTilde
+	dln1, dln2, err := p.preParams.DlnProof(p.params.session(i))
+	if err != nil {
+		return nil, err
+	}
+
+	p.temp.ui, p.temp.vs, p.temp.shares, p.temp.deCommit = ui, vs, shares, cmtDeCmt.D
+	pk := &p.preParams.PaillierSK.PublicKey
+	p.data.LocalPreParams = p.preParams
+	p.data.ShareID = p.params.PartyIDs[i]
+	p.data.Threshold = p.params.Threshold
+	copy(p.data.Ks, p.params.PartyIDs)
+	p.data.PaillierPKs[i] = pk
+	p.data.NTildej[i], p.data.H1j[i], p.data.H2j[i] = p.preParams.NTildei, p.preParams.H1i, p.preParams.H2i
+
+	return []*Message{{
+		From: i,
+		To:   Broadcast,
+		Content: &KGRound1Message{
+			Commitment: cmtDeCmt.C,
+			PaillierN:  pk.N,
+			NTilde:     p.preParams.NTildei,
+			H1:         p.preParams.H1i,
+			H2:         p.preParams.H2i,
+			Dln1:       dln1,
+			Dln2:       dln2,
+		},
+	}}, nil
+}
+
+// Round2 verifies everyone's DLN proofs, then sends each party its share with a no small factor proof
+// and broadcasts the opening of our commitment along with a Paillier-Blum modulus proof and a Schnorr proof of u_i.
+func (p *LocalParty) Round2() ([]*Message, error) {
+	if p.round != 1 || !p.canProceed() {
+		return nil, errors.New("keygen: Round2 is not ready")
+	}
+	p.round = 2
+	ec, i := p.params.EC, p.params.Index
+
+	// 1. verify the other parties' ring-Pedersen parameters
+	h1H2Map := make(map[string]int, 2*p.params.PartyCount())
+	h1H2Map[p.preParams.H1i.String()] = i
+	h1H2Map[p.preParams.H2i.String()] = i
+	for j, msg := range p.temp.r1msgs {
+		if j == i {
+			continue
+		}
+		for _, h := range []*big.Int{msg.H1, msg.H2} {
+			if k, ok := h1H2Map[h.String()]; ok {
+				return nil, fmt.Errorf("keygen round 2: h1j or h2j of party %d was already used by party %d", j, k)
+			}
+			h1H2Map[h.String()] = j
+		}
+		paramsj := &proof.PaillierParams{
+			PaillierSK: &paillier.PrivateKey{PublicKey: paillier.PublicKey{N: msg.PaillierN}},
+			NTildei:    msg.NTilde,
+			H1i:        msg.H1,
+			H2i:        msg.H2,
+		}
+		if err := paramsj.VerifyDln(msg.Dln1, msg.Dln2, p.params.session(j)); err != nil {
+			return nil, fmt.Errorf("keygen round 2: dln proof from party %d failed: %v", j, err)
+		}
+		p.data.PaillierPKs[j] = &paramsj.PaillierSK.PublicKey
+		p.data.NTildej[j], p.data.H1j[j], p.data.H2j[j] = msg.NTilde, msg.H1, msg.H2
+	}
+
+	// 2. p2p: the share for each party, and a no small factor proof against its NTilde
+	out := make([]*Message, 0, p.params.PartyCount())
+	for j := range p.params.PartyIDs {
+		if j == i {
+			continue
+		}
+		fac, err := p.preParams.FacProof(ec, p.data.PaillierParamsj(j), p.params.session(i))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, &Message{
+			From:    i,
+			To:      j,
+			Content: &KGRound2Message1{Share: p.temp.shares[j].Share, FacProof: fac},
+		})
+	}
+
+	// 3. broadcast: de-commitment, modulus proof and proof of u_i
+	modProof, err := p.preParams.PaillierSK.ModProof(p.params.session(i))
+	if err != nil {
+		return nil, err
+	}
+	uProof, err := schnorr.NewZKProof(p.temp.ui, p.temp.vs[0], p.params.session(i))
+	if err != nil {
+		return nil, err
+	}
+	modBzs, uBzs := modProof.Bytes(), uProof.Bytes()
+	out = append(out, &Message{
+		From: i,
+		To:   Broadcast,
+		Content: &KGRound2Message2{
+			DeCommitment: p.temp.deCommit,
+			ModProof:     modBzs[:],
+			UProof:       uBzs[:],
+		},
+	})
+	return out, nil
+}
+
+// Round3 opens the other parties' commitments, verifies their proofs and our shares, then computes our share x_i,
+// every public share X_j and the public key, and broadcasts a Schnorr proof of x_i.
+func (p *LocalParty) Round3() ([]*Message, error) {
+	if p.round != 2 || !p.canProceed() {
+		return nil, errors.New("keygen: Round3 is not ready")
+	}
+	p.round = 3
+	ec, i := p.params.EC, p.params.Index
+	q := ec.Params().N
+	threshold := p.params.Threshold
+
+	// 1-4. verify the other parties' messages
+	allVs := make([]vss.Vs, p.params.PartyCount())
+	allVs[i] = p.temp.vs
+	xi := new(big.Int).Set(p.temp.shares[i].Share)
+	for j := range p.params.PartyIDs {
+		if j == i {
+			continue
+		}
+		r1msg, r2msg1, r2msg2 := p.temp.r1msgs[j], p.temp.r2msgs1[j], p.temp.r2msgs2[j]
+		vsj, err := p.openCommitment(r1msg.Commitment, r2msg2.DeCommitment)
+		if err != nil {
+			return nil, fmt.Errorf("keygen round 3: de-commitment from party %d failed: %v", j, err)
+		}
+		modProof, err := paillier.ModProofFromBytes(r2msg2.ModProof)
+		if err == nil {
+			err = modProof.VerifyWithReason(r1msg.PaillierN, p.params.session(j))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("keygen round 3: mod proof from party %d failed: %v", j, err)
+		}
+		if err = p.data.PaillierParamsj(j).VerifyFac(ec, p.preParams, r2msg1.FacProof, p.params.session(j)); err != nil {
+			return nil, fmt.Errorf("keygen round 3: fac proof from party %d failed: %v", j, err)
+		}
+		uProof, err := schnorr.NewZKProofFromBytes(vsj[0], r2msg2.UProof)
+		if err != nil || !uProof.Verify(vsj[0], p.params.session(j)) {
+			return nil, fmt.Errorf("keygen round 3: proof of u_j from party %d failed", j)
+		}
+		share := &vss.Share{Threshold: threshold, ID: p.params.PartyIDs[i], Share: r2msg1.Share}
+		if !share.Verify(ec, threshold, vsj) {
+			return nil, fmt.Errorf("keygen round 3: vss share from party %d failed", j)
+		}
+		allVs[j] = vsj
+		xi = xi.Add(xi, r2msg1.Share)
+	}
+	xi = xi.Mod(xi, q)
+
+	// 5. the commitments to the sum of the polynomials
+	vc := make(vss.Vs, threshold+1)
+	for c := range vc {
+		vc[c] = allVs[0][c]
+		for j := 1; j < len(allVs); j++ {
+			sum, err := vc[c].Add(allVs[j][c])
+			if err != nil {
+				return nil, fmt.Errorf("keygen round 3: summing the vss commitments failed: %v", err)
+			}
+			vc[c] = sum
+		}
+	}
+
+	// 6. X_j = prod vc_c^(k_j^c) for every party
+	for j, kj := range p.params.PartyIDs {
+		Xj, err := vc.EvaluateAt(ec, kj)
+		if err != nil {
+			return nil, fmt.Errorf("keygen round 3: computing X_j of party %d failed: %v", j, err)
+		}
+		p.data.BigXj[j] = Xj
+	}
+	if !curve.ScalarBaseMult(ec, xi).Equals(p.data.BigXj[i]) {
+		return nil, errors.New("keygen round 3: our share does not match X_i")
+	}
+	p.data.Xi = xi
+	p.data.ECDSAPub = vc[0]
+
+	xiProof, err := schnorr.NewZKProof(xi, p.data.BigXj[i], p.params.session(i))
+	if err != nil {
+		return nil, err
+	}
+	bzs := xiProof.Bytes()
+	return []*Message{{
+		From:    i,
+		To:      Broadcast,
+		Content: &KGRound3Message{XiProof: bzs[:]},
+	}}, nil
+}
+
+// finish verifies the other parties' proofs of their shares x_j
+func (p *LocalParty) finish() error {
+	for j, msg := range p.temp.r3msgs {
+		if j == p.params.Index {
+			continue
+		}
+		pf, err := schnorr.NewZKProofFromBytes(p.data.BigXj[j], msg.XiProof)
+		if err != nil || !pf.Verify(p.data.BigXj[j], p.params.session(j)) {
+			return fmt.Errorf("keygen finish: proof of x_j from party %d failed", j)
+		}
+	}
+	p.done = true
+	return nil
+}
+
+// ----- utils
+
+func (p *LocalParty) openCommitment(C *big.Int, D cmt.HashDeCommitment) (vss.Vs, error) {
+	ok, flatVs := (&cmt.HashCommitDecommit{C: C, D: D}).DeCommit()
+	if !ok {
+		return nil, errors.New("the de-commitment does not match the commitment")
+	}
+	vs, err := vss.VsUnFlat(p.params.EC, flatVs)
+	if err != nil {
+		return nil, err
+	}
+	if len(vs) != p.params.Threshold+1 {
+		return nil, fmt.Errorf("expected %d vss commitments but got %d", p.params.Threshold+1, len(vs))
+	}
+	return vs, nil
+}
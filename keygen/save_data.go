@@ -0,0 +1,61 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package keygen
+
+import (
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/paillier"
+	"github.com/zhp12543/zk-proof/proof"
+	"math/big"
+)
+
+type (
+	// LocalPartySaveData is the key share of one party. Every slice is indexed by party position in Ks.
+	LocalPartySaveData struct {
+		// our Paillier key pair and ring-Pedersen secrets
+		LocalPreParams *proof.PaillierParams
+
+		// our share x_i and Shamir index
+		Xi, ShareID *big.Int
+		Threshold   int
+		Ks          []*big.Int
+
+		// the other parties' ring-Pedersen parameters, used as verifier params in the signing proofs
+		NTildej, H1j, H2j []*big.Int
+
+		// public shares X_j = g^x_j and Paillier public keys of all parties
+		BigXj       []*curve.ECPoint
+		PaillierPKs []*paillier.PublicKey
+
+		// the ECDSA public key
+		ECDSAPub *curve.ECPoint
+	}
+)
+
+func newLocalPartySaveData(partyCount int) LocalPartySaveData {
+	return LocalPartySaveData{
+		Ks:          make([]*big.Int, partyCount),
+		NTildej:     make([]*big.Int, partyCount),
+		H1j:         make([]*big.Int, partyCount),
+		H2j:         make([]*big.Int, partyCount),
+		BigXj:       make([]*curve.ECPoint, partyCount),
+		PaillierPKs: make([]*paillier.PublicKey, partyCount),
+	}
+}
+
+// PaillierParamsj returns the public Paillier and ring-Pedersen parameters of party j
+func (data *LocalPartySaveData) PaillierParamsj(j int) *proof.PaillierParams {
+	if j < 0 || len(data.PaillierPKs) <= j || data.PaillierPKs[j] == nil {
+		return nil
+	}
+	return &proof.PaillierParams{
+		PaillierSK: &paillier.PrivateKey{PublicKey: *data.PaillierPKs[j]},
+		NTildei:    data.NTildej[j],
+		H1i:        data.H1j[j],
+		H2i:        data.H2j[j],
+	}
+}
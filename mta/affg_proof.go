@@ -0,0 +1,246 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package mta
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"github.com/zhp12543/zk-proof/abort"
+	"github.com/zhp12543/zk-proof/cmt"
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/paillier"
+	"github.com/zhp12543/zk-proof/prime"
+	"math/big"
+)
+
+const (
+	ProofAffgBytesParts = 14
+)
+
+type (
+	// ProofAffg is the CGGMP21 affine operation with group commitment proof Πaff-g (Fig. 15).
+	// Unlike ProofBobWC, y is additionally encrypted as Y under the prover's own Paillier key pk1.
+	ProofAffg struct {
+		A                                     *big.Int
+		Bx                                    *curve.ECPoint
+		By, E, S, F, T, Z1, Z2, Z3, Z4, W, Wy *big.Int
+	}
+)
+
+// ProveAffg proves D = C^x * Enc_pk0(y; rho), Y = Enc_pk1(y; rhoY) and X = g^x, with x < q and y < q^5 as in BobMid.
+// NCap, s, t are the verifier's ring-Pedersen parameters.
+func ProveAffg(ec elliptic.Curve, pk0, pk1 *paillier.PublicKey, NCap, s, t, C, D, Y *big.Int, X *curve.ECPoint, x, y, rho, rhoY *big.Int, optionalSession ...*cmt.Session) (*ProofAffg, error) {
+	if ec == nil || pk0 == nil || pk1 == nil || NCap == nil || s == nil || t == nil || C == nil || D == nil || Y == nil || X == nil ||
+		x == nil || y == nil || rho == nil || rhoY == nil {
+		return nil, errors.New("ProveAffg() received a nil argument")
+	}
+	q := ec.Params().N
+	bounds := newAffgBounds(q)
+	if !prime.IsInInterval(x, q) || !prime.IsInInterval(y, bounds.lPrm) {
+		return nil, errors.New("ProveAffg() x or y is out of range")
+	}
+
+	N0, N1 := pk0.N, pk1.N
+	modN0, modN1 := prime.ModInt(N0), prime.ModInt(N1)
+	modN0Squared, modN1Squared := prime.ModInt(pk0.NSquare()), prime.ModInt(pk1.NSquare())
+	modNCap := prime.ModInt(NCap)
+	lNCap := new(big.Int).Mul(bounds.l, NCap)
+	lEpsNCap := new(big.Int).Mul(bounds.lEps, NCap)
+
+	for {
+		// 1. sample
+		alpha := curve.GetRandomPositiveInt(bounds.lEps)
+		beta := curve.GetRandomPositiveInt(bounds.lPrmEps)
+		r := curve.GetRandomPositiveRelativelyPrimeInt(N0)
+		rY := curve.GetRandomPositiveRelativelyPrimeInt(N1)
+		gamma := curve.GetRandomPositiveInt(lEpsNCap)
+		m := curve.GetRandomPositiveInt(lNCap)
+		delta := curve.GetRandomPositiveInt(lEpsNCap)
+		mu := curve.GetRandomPositiveInt(lNCap)
+
+		// 2. compute
+		A := modN0Squared.Mul(modN0Squared.Exp(C, alpha), modN0Squared.Exp(pk0.Gamma(), beta))
+		A = modN0Squared.Mul(A, modN0Squared.Exp(r, N0))
+		Bx := curve.ScalarBaseMult(ec, alpha)
+		By := modN1Squared.Mul(modN1Squared.Exp(pk1.Gamma(), beta), modN1Squared.Exp(rY, N1))
+		E := modNCap.Mul(modNCap.Exp(s, alpha), modNCap.Exp(t, gamma))
+		S := modNCap.Mul(modNCap.Exp(s, x), modNCap.Exp(t, m))
+		F := modNCap.Mul(modNCap.Exp(s, beta), modNCap.Exp(t, delta))
+		T := modNCap.Mul(modNCap.Exp(s, y), modNCap.Exp(t, mu))
+
+		// 3. e
+		e := affgChallenge(q, pk0, pk1, NCap, s, t, C, D, Y, X, A, Bx, By, E, S, F, T, cmt.OptionalSession(optionalSession))
+
+		// 4.
+		z1 := new(big.Int).Mul(e, x)
+		z1 = z1.Add(z1, alpha)
+		z2 := new(big.Int).Mul(e, y)
+		z2 = z2.Add(z2, beta)
+		if !prime.IsInInterval(z1, bounds.lEps) || !prime.IsInInterval(z2, bounds.lPrmEps) {
+			continue // would fail the range check; resample
+		}
+		z3 := new(big.Int).Mul(e, m)
+		z3 = z3.Add(z3, gamma)
+		z4 := new(big.Int).Mul(e, mu)
+		z4 = z4.Add(z4, delta)
+		w := modN0.Mul(r, modN0.Exp(rho, e))
+		wY := modN1.Mul(rY, modN1.Exp(rhoY, e))
+
+		return &ProofAffg{A: A, Bx: Bx, By: By, E: E, S: S, F: F, T: T, Z1: z1, Z2: z2, Z3: z3, Z4: z4, W: w, Wy: wY}, nil
+	}
+}
+
+func ProofAffgFromBytes(ec elliptic.Curve, bzs [][]byte) (*ProofAffg, error) {
+	if !curve.NonEmptyMultiBytes(bzs, ProofAffgBytesParts) {
+		return nil, fmt.Errorf("expected %d byte parts to construct ProofAffg", ProofAffgBytesParts)
+	}
+	return ProofAffgUnFlat(ec, curve.MultiBytesToBigInts(bzs))
+}
+
+func (pf *ProofAffg) Verify(ec elliptic.Curve, pk0, pk1 *paillier.PublicKey, NCap, s, t, C, D, Y *big.Int, X *curve.ECPoint, optionalSession ...*cmt.Session) bool {
+	return pf.VerifyWithReason(ec, pk0, pk1, NCap, s, t, C, D, Y, X, optionalSession...) == nil
+}
+
+// VerifyWithReason is Verify returning an *abort.VerifyError that names the failed check of CGGMP21 Fig. 15
+func (pf *ProofAffg) VerifyWithReason(ec elliptic.Curve, pk0, pk1 *paillier.PublicKey, NCap, s, t, C, D, Y *big.Int, X *curve.ECPoint, optionalSession ...*cmt.Session) error {
+	const name = "ProofAffg"
+	if pf == nil || !pf.ValidateBasic() || ec == nil || pk0 == nil || pk1 == nil || NCap == nil || s == nil || t == nil ||
+		C == nil || D == nil || Y == nil || !X.ValidateBasic() {
+		return abort.NewMalformedError(name, "nil proof or statement value(s), or an invalid X")
+	}
+	q := ec.Params().N
+	bounds := newAffgBounds(q)
+	N0, N1 := pk0.N, pk1.N
+	N0Squared, N1Squared := pk0.NSquare(), pk1.NSquare()
+
+	for _, v := range []*big.Int{C, D, pf.A} {
+		if !curve.IsNumberInMultiplicativeGroup(N0Squared, v) {
+			return abort.NewMalformedError(name, "C, D or A is not in Z*_N0^2")
+		}
+	}
+	for _, v := range []*big.Int{Y, pf.By} {
+		if !curve.IsNumberInMultiplicativeGroup(N1Squared, v) {
+			return abort.NewMalformedError(name, "Y or By is not in Z*_N1^2")
+		}
+	}
+	for _, v := range []*big.Int{pf.E, pf.S, pf.F, pf.T} {
+		if !curve.IsNumberInMultiplicativeGroup(NCap, v) {
+			return abort.NewMalformedError(name, "E, S, F or T is not in Z*_NCap")
+		}
+	}
+	if !curve.IsNumberInMultiplicativeGroup(N0, pf.W) || !curve.IsNumberInMultiplicativeGroup(N1, pf.Wy) {
+		return abort.NewMalformedError(name, "w is not in Z*_N0 or wy is not in Z*_N1")
+	}
+	if pf.Z3.Sign() == -1 || pf.Z4.Sign() == -1 {
+		return abort.NewMalformedError(name, "z3 or z4 is negative")
+	}
+
+	// range checks
+	if !prime.IsInInterval(pf.Z1, bounds.lEps) || !prime.IsInInterval(pf.Z2, bounds.lPrmEps) {
+		return abort.NewRejectedError(name, "range check", "z1 or z2 is out of range")
+	}
+
+	e := affgChallenge(q, pk0, pk1, NCap, s, t, C, D, Y, X, pf.A, pf.Bx, pf.By, pf.E, pf.S, pf.F, pf.T, cmt.OptionalSession(optionalSession))
+
+	{ // C^z1 * (1+N0)^z2 * w^N0 = A * D^e mod N0^2
+		modN0Squared := prime.ModInt(N0Squared)
+		left := modN0Squared.Mul(modN0Squared.Exp(C, pf.Z1), modN0Squared.Exp(pk0.Gamma(), pf.Z2))
+		left = modN0Squared.Mul(left, modN0Squared.Exp(pf.W, N0))
+		right := modN0Squared.Mul(pf.A, modN0Squared.Exp(D, e))
+		if left.Cmp(right) != 0 {
+			return abort.NewRejectedError(name, "equality check 1", "C^z1 * (1+N0)^z2 * w^N0 != A * D^e mod N0^2")
+		}
+	}
+
+	{ // g^z1 = Bx * X^e
+		z1ModQ := new(big.Int).Mod(pf.Z1, q)
+		left := curve.ScalarBaseMult(ec, z1ModQ)
+		right, err := X.ScalarMult(e).Add(pf.Bx)
+		if err != nil || !left.Equals(right) {
+			return abort.NewRejectedError(name, "equality check 2", "g^z1 != Bx * X^e")
+		}
+	}
+
+	{ // (1+N1)^z2 * wy^N1 = By * Y^e mod N1^2
+		modN1Squared := prime.ModInt(N1Squared)
+		left := modN1Squared.Mul(modN1Squared.Exp(pk1.Gamma(), pf.Z2), modN1Squared.Exp(pf.Wy, N1))
+		right := modN1Squared.Mul(pf.By, modN1Squared.Exp(Y, e))
+		if left.Cmp(right) != 0 {
+			return abort.NewRejectedError(name, "equality check 3", "(1+N1)^z2 * wy^N1 != By * Y^e mod N1^2")
+		}
+	}
+
+	{ // s^z1 * t^z3 = E * S^e, s^z2 * t^z4 = F * T^e mod NCap
+		modNCap := prime.ModInt(NCap)
+		left := modNCap.Mul(modNCap.Exp(s, pf.Z1), modNCap.Exp(t, pf.Z3))
+		right := modNCap.Mul(pf.E, modNCap.Exp(pf.S, e))
+		if left.Cmp(right) != 0 {
+			return abort.NewRejectedError(name, "equality check 4", "s^z1 * t^z3 != E * S^e mod NCap")
+		}
+		left = modNCap.Mul(modNCap.Exp(s, pf.Z2), modNCap.Exp(t, pf.Z4))
+		right = modNCap.Mul(pf.F, modNCap.Exp(pf.T, e))
+		if left.Cmp(right) != 0 {
+			return abort.NewRejectedError(name, "equality check 5", "s^z2 * t^z4 != F * T^e mod NCap")
+		}
+	}
+	return nil
+}
+
+func (pf *ProofAffg) ValidateBasic() bool {
+	return pf.A != nil &&
+		pf.Bx.ValidateBasic() &&
+		pf.By != nil &&
+		pf.E != nil &&
+		pf.S != nil &&
+		pf.F != nil &&
+		pf.T != nil &&
+		pf.Z1 != nil &&
+		pf.Z2 != nil &&
+		pf.Z3 != nil &&
+		pf.Z4 != nil &&
+		pf.W != nil &&
+		pf.Wy != nil
+}
+
+func (pf *ProofAffg) Bytes() [ProofAffgBytesParts][]byte {
+	var out [ProofAffgBytesParts][]byte
+	for i, part := range pf.Flat() {
+		out[i] = part.Bytes()
+	}
+	return out
+}
+
+// ----- utils
+
+// affgBounds holds 2^ℓ, 2^(ℓ+ε) and 2^(ℓ'+ε) with ℓ = |q|, ℓ' = 5|q| and ε = 2|q|, matching the q and q^5 ranges of BobMid
+type affgBounds struct {
+	l, lEps, lPrm, lPrmEps *big.Int
+}
+
+func newAffgBounds(q *big.Int) *affgBounds {
+	l := q.BitLen()
+	lPrm, eps := 5*l, 2*l
+	return &affgBounds{
+		l:       new(big.Int).Lsh(one, uint(l)),
+		lEps:    new(big.Int).Lsh(one, uint(l+eps)),
+		lPrm:    new(big.Int).Lsh(one, uint(lPrm)),
+		lPrmEps: new(big.Int).Lsh(one, uint(lPrm+eps)),
+	}
+}
+
+// affgChallenge derives e over both Paillier keys, the ring-Pedersen parameters and the statement
+func affgChallenge(q *big.Int, pk0, pk1 *paillier.PublicKey, NCap, s, t, C, D, Y *big.Int, X *curve.ECPoint,
+	A *big.Int, Bx *curve.ECPoint, By, E, S, F, T *big.Int, session *cmt.Session) *big.Int {
+	statement := append(pk0.AsInts(), pk1.AsInts()...)
+	ch := &cmt.Challenge{
+		Domain:     "zk-proof/mta/affg/v1",
+		Statement:  append(statement, NCap, s, t, C, D, Y, X.X(), X.Y()),
+		Commitment: []*big.Int{A, Bx.X(), Bx.Y(), By, E, S, F, T},
+	}
+	return ch.Scalar(session, q)
+}
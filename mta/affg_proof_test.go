@@ -0,0 +1,124 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package mta
+
+import (
+	"crypto/elliptic"
+	"github.com/zhp12543/zk-proof/abort"
+	"github.com/zhp12543/zk-proof/curve"
+	"math/big"
+	"testing"
+)
+
+func TestProofAffg(t *testing.T) {
+	ec := elliptic.P256()
+	q := ec.Params().N
+	alice, bob := setUp(t)
+
+	// Alice's k encrypted under her key; Bob computes D = C^x * Enc_A(y) and Y = Enc_B(y)
+	k := curve.GetRandomPositiveInt(q)
+	C, err := alice.pk.Encrypt(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := curve.GetRandomPositiveInt(q)
+	X := curve.ScalarBaseMult(ec, x)
+	// y may be up to 2^(5|q|) but must fit in the small test modulus
+	y := curve.MustGetRandomInt(testPaillierKeyLength / 2)
+	cY, rho, err := alice.pk.EncryptAndReturnRandomness(y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	D, err := alice.pk.HomoMult(x, C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if D, err = alice.pk.HomoAdd(D, cY); err != nil {
+		t.Fatal(err)
+	}
+	Y, rhoY, err := bob.pk.EncryptAndReturnRandomness(y)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pf, err := ProveAffg(ec, alice.pk, bob.pk, alice.NTilde, alice.h1, alice.h2, C, D, Y, X, x, y, rho, rhoY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pf.Verify(ec, alice.pk, bob.pk, alice.NTilde, alice.h1, alice.h2, C, D, Y, X) {
+		t.Fatal("ProofAffg did not verify")
+	}
+	bzs := pf.Bytes()
+	pf2, err := ProofAffgFromBytes(ec, bzs[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pf2.Verify(ec, alice.pk, bob.pk, alice.NTilde, alice.h1, alice.h2, C, D, Y, X) {
+		t.Fatal("ProofAffg did not verify after a bytes round trip")
+	}
+	pf3, err := ProofAffgUnFlat(ec, pf.Flat())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pf3.Verify(ec, alice.pk, bob.pk, alice.NTilde, alice.h1, alice.h2, C, D, Y, X) {
+		t.Fatal("ProofAffg did not verify after a flat round trip")
+	}
+
+	// D must decrypt to k*x + y
+	alphaPrm, err := alice.sk.Decrypt(D)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := new(big.Int).Mul(k, x)
+	if expected.Add(expected, y).Cmp(alphaPrm) != 0 {
+		t.Fatal("D does not encrypt k*x + y")
+	}
+
+	otherX := curve.ScalarBaseMult(ec, new(big.Int).Add(x, one))
+	if pf.Verify(ec, alice.pk, bob.pk, alice.NTilde, alice.h1, alice.h2, C, D, Y, otherX) {
+		t.Error("ProofAffg verified for another X")
+	}
+	otherY, err := bob.pk.Encrypt(y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pf.Verify(ec, alice.pk, bob.pk, alice.NTilde, alice.h1, alice.h2, C, D, otherY, X) {
+		t.Error("ProofAffg verified for another Y")
+	}
+
+	verify := func(pf *ProofAffg) error {
+		return pf.VerifyWithReason(ec, alice.pk, bob.pk, alice.NTilde, alice.h1, alice.h2, C, D, Y, X)
+	}
+	bad := *pf
+	bad.Z3 = big.NewInt(-1)
+	expectReason(t, "negative z3", verify(&bad), abort.Malformed, "")
+	bad = *pf
+	bad.Z1 = new(big.Int).Lsh(one, uint(pf.Z1.BitLen()+q.BitLen()))
+	expectReason(t, "z1 out of range", verify(&bad), abort.Rejected, "range check")
+	bad = *pf
+	bad.W = new(big.Int).Add(pf.W, one)
+	expectReason(t, "w changed", verify(&bad), abort.Rejected, "equality check 1")
+	bad = *pf
+	bad.Wy = new(big.Int).Add(pf.Wy, one)
+	expectReason(t, "wy changed", verify(&bad), abort.Rejected, "equality check 3")
+	bad = *pf
+	bad.Z4 = new(big.Int).Add(pf.Z4, one)
+	expectReason(t, "z4 changed", verify(&bad), abort.Rejected, "equality check 5")
+
+	session, other := testSessions()
+	pf, err = ProveAffg(ec, alice.pk, bob.pk, alice.NTilde, alice.h1, alice.h2, C, D, Y, X, x, y, rho, rhoY, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pf.Verify(ec, alice.pk, bob.pk, alice.NTilde, alice.h1, alice.h2, C, D, Y, X, session) {
+		t.Fatal("ProofAffg did not verify in its session")
+	}
+	if pf.Verify(ec, alice.pk, bob.pk, alice.NTilde, alice.h1, alice.h2, C, D, Y, X, other) ||
+		pf.Verify(ec, alice.pk, bob.pk, alice.NTilde, alice.h1, alice.h2, C, D, Y, X) {
+		t.Error("ProofAffg verified outside its session")
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package mta
+
+import (
+	"fmt"
+)
+
+const (
+	// steps of BobMid, BobMidWC, AliceEnd and AliceEndWC that depend on the counterparty's message
+	StepDecode       = "decode"
+	StepVerify       = "verify"
+	StepCiphertext   = "validate ciphertext"
+	StepDecryptReply = "decrypt reply"
+)
+
+type (
+	// ProofError is returned when a check on the counterparty's MtA message fails, and identifies it as the culprit.
+	// Errors of our own computation are returned as plain errors.
+	ProofError struct {
+		Proof string // e.g. "RangeProofAlice"
+		Step  string
+		Err   error // the underlying cause, if any
+	}
+)
+
+func (err *ProofError) Error() string {
+	if err.Err != nil {
+		return fmt.Sprintf("%s: %s failed: %v", err.Proof, err.Step, err.Err)
+	}
+	return fmt.Sprintf("%s: %s failed", err.Proof, err.Step)
+}
+
+func (err *ProofError) Unwrap() error {
+	return err.Err
+}
@@ -92,3 +92,101 @@ func RangeProofAliceUnFlat(in []*big.Int) (*RangeProofAlice, error) {
 	}, nil
 }
 
+func (pf *ProofAffg) Flat() []*big.Int {
+	return []*big.Int{
+		pf.A,
+		pf.Bx.X(),
+		pf.Bx.Y(),
+		pf.By,
+		pf.E,
+		pf.S,
+		pf.F,
+		pf.T,
+		pf.Z1,
+		pf.Z2,
+		pf.Z3,
+		pf.Z4,
+		pf.W,
+		pf.Wy,
+	}
+}
+
+func ProofAffgUnFlat(ec elliptic.Curve, in []*big.Int) (*ProofAffg, error) {
+	if len(in) != ProofAffgBytesParts {
+		return nil, fmt.Errorf("expected %d big.Int parts to construct ProofAffg", ProofAffgBytesParts)
+	}
+	point, err := curve.NewECPoint(ec, in[1], in[2])
+	if err != nil {
+		return nil, err
+	}
+	return &ProofAffg{
+		A:  in[0],
+		Bx: point,
+		By: in[3],
+		E:  in[4],
+		S:  in[5],
+		F:  in[6],
+		T:  in[7],
+		Z1: in[8],
+		Z2: in[9],
+		Z3: in[10],
+		Z4: in[11],
+		W:  in[12],
+		Wy: in[13],
+	}, nil
+}
+
+func (pf *ProofMul) Flat() []*big.Int {
+	return []*big.Int{
+		pf.A,
+		pf.B,
+		pf.Z,
+		pf.U,
+		pf.V,
+	}
+}
+
+func ProofMulUnFlat(in []*big.Int) (*ProofMul, error) {
+	if len(in) != ProofMulBytesParts {
+		return nil, fmt.Errorf("expected %d big.Int parts to construct ProofMul", ProofMulBytesParts)
+	}
+	return &ProofMul{
+		A: in[0],
+		B: in[1],
+		Z: in[2],
+		U: in[3],
+		V: in[4],
+	}, nil
+}
+
+func (pf *ProofLogStar) Flat() []*big.Int {
+	return []*big.Int{
+		pf.S,
+		pf.A,
+		pf.Y.X(),
+		pf.Y.Y(),
+		pf.D,
+		pf.Z1,
+		pf.Z2,
+		pf.Z3,
+	}
+}
+
+func ProofLogStarUnFlat(ec elliptic.Curve, in []*big.Int) (*ProofLogStar, error) {
+	if len(in) != ProofLogStarBytesParts {
+		return nil, fmt.Errorf("expected %d big.Int parts to construct ProofLogStar", ProofLogStarBytesParts)
+	}
+	point, err := curve.NewECPoint(ec, in[2], in[3])
+	if err != nil {
+		return nil, err
+	}
+	return &ProofLogStar{
+		S:  in[0],
+		A:  in[1],
+		Y:  point,
+		D:  in[4],
+		Z1: in[5],
+		Z2: in[6],
+		Z3: in[7],
+	}, nil
+}
@@ -0,0 +1,205 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package mta
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"github.com/zhp12543/zk-proof/abort"
+	"github.com/zhp12543/zk-proof/cmt"
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/paillier"
+	"github.com/zhp12543/zk-proof/prime"
+	"math/big"
+)
+
+const (
+	ProofLogStarBytesParts = 8
+)
+
+type (
+	// ProofLogStar is the CGGMP21 knowledge of exponent vs Paillier encryption proof Πlog* (Fig. 25).
+	// It shows that C = Enc(x; rho) and X = G^x share the same x < q.
+	ProofLogStar struct {
+		S, A          *big.Int
+		Y             *curve.ECPoint
+		D, Z1, Z2, Z3 *big.Int
+	}
+)
+
+// ProveLogStar proves C = Enc_pk(x; rho) and X = G^x with x < q. If G is nil the curve generator is used.
+// NCap, s, t are the verifier's ring-Pedersen parameters.
+func ProveLogStar(ec elliptic.Curve, pk *paillier.PublicKey, NCap, s, t, C *big.Int, X, G *curve.ECPoint, x, rho *big.Int, optionalSession ...*cmt.Session) (*ProofLogStar, error) {
+	if ec == nil || pk == nil || NCap == nil || s == nil || t == nil || C == nil || X == nil || x == nil || rho == nil {
+		return nil, errors.New("ProveLogStar() received a nil argument")
+	}
+	q := ec.Params().N
+	if !prime.IsInInterval(x, q) {
+		return nil, errors.New("ProveLogStar() x is out of range")
+	}
+	G = logStarBase(ec, G)
+	bounds := newAffgBounds(q)
+
+	N0 := pk.N
+	modN0, modN0Squared := prime.ModInt(N0), prime.ModInt(pk.NSquare())
+	modNCap := prime.ModInt(NCap)
+	lNCap := new(big.Int).Mul(bounds.l, NCap)
+	lEpsNCap := new(big.Int).Mul(bounds.lEps, NCap)
+
+	for {
+		// 1. sample
+		alpha := curve.GetRandomPositiveInt(bounds.lEps)
+		mu := curve.GetRandomPositiveInt(lNCap)
+		r := curve.GetRandomPositiveRelativelyPrimeInt(N0)
+		gamma := curve.GetRandomPositiveInt(lEpsNCap)
+
+		// 2. compute
+		S := modNCap.Mul(modNCap.Exp(s, x), modNCap.Exp(t, mu))
+		A := modN0Squared.Mul(modN0Squared.Exp(pk.Gamma(), alpha), modN0Squared.Exp(r, N0))
+		Y := G.ScalarMult(new(big.Int).Mod(alpha, q))
+		if Y == nil {
+			continue // alpha = 0 mod q
+		}
+		D := modNCap.Mul(modNCap.Exp(s, alpha), modNCap.Exp(t, gamma))
+
+		// 3. e
+		e := logStarChallenge(q, pk, NCap, s, t, C, X, G, S, A, Y, D, cmt.OptionalSession(optionalSession))
+
+		// 4.
+		z1 := new(big.Int).Mul(e, x)
+		z1 = z1.Add(z1, alpha)
+		if !prime.IsInInterval(z1, bounds.lEps) {
+			continue // would fail the range check; resample
+		}
+		z2 := modN0.Mul(r, modN0.Exp(rho, e))
+		z3 := new(big.Int).Mul(e, mu)
+		z3 = z3.Add(z3, gamma)
+
+		return &ProofLogStar{S: S, A: A, Y: Y, D: D, Z1: z1, Z2: z2, Z3: z3}, nil
+	}
+}
+
+func ProofLogStarFromBytes(ec elliptic.Curve, bzs [][]byte) (*ProofLogStar, error) {
+	if !curve.NonEmptyMultiBytes(bzs, ProofLogStarBytesParts) {
+		return nil, fmt.Errorf("expected %d byte parts to construct ProofLogStar", ProofLogStarBytesParts)
+	}
+	return ProofLogStarUnFlat(ec, curve.MultiBytesToBigInts(bzs))
+}
+
+// Verify checks the proof for C and X = G^x. If G is nil the curve generator is used.
+// Off-curve and identity points for X, G and the commitment Y are rejected.
+func (pf *ProofLogStar) Verify(ec elliptic.Curve, pk *paillier.PublicKey, NCap, s, t, C *big.Int, X, G *curve.ECPoint, optionalSession ...*cmt.Session) bool {
+	return pf.VerifyWithReason(ec, pk, NCap, s, t, C, X, G, optionalSession...) == nil
+}
+
+// VerifyWithReason is Verify returning an *abort.VerifyError that names the failed check of CGGMP21 Fig. 25
+func (pf *ProofLogStar) VerifyWithReason(ec elliptic.Curve, pk *paillier.PublicKey, NCap, s, t, C *big.Int, X, G *curve.ECPoint, optionalSession ...*cmt.Session) error {
+	const name = "ProofLogStar"
+	if pf == nil || !pf.ValidateBasic() || ec == nil || pk == nil || NCap == nil || s == nil || t == nil || C == nil {
+		return abort.NewMalformedError(name, "nil proof or statement value(s)")
+	}
+	q := ec.Params().N
+	bounds := newAffgBounds(q)
+	G = logStarBase(ec, G)
+	for _, point := range []*curve.ECPoint{X, G, pf.Y} {
+		if !point.ValidateBasic() || point.IsIdentity() {
+			return abort.NewMalformedError(name, "X, G or Y is off the curve or the identity")
+		}
+	}
+	N0, N0Squared := pk.N, pk.NSquare()
+
+	for _, v := range []*big.Int{C, pf.A} {
+		if !curve.IsNumberInMultiplicativeGroup(N0Squared, v) {
+			return abort.NewMalformedError(name, "C or A is not in Z*_N0^2")
+		}
+	}
+	for _, v := range []*big.Int{pf.S, pf.D} {
+		if !curve.IsNumberInMultiplicativeGroup(NCap, v) {
+			return abort.NewMalformedError(name, "S or D is not in Z*_NCap")
+		}
+	}
+	if !curve.IsNumberInMultiplicativeGroup(N0, pf.Z2) || pf.Z3.Sign() == -1 {
+		return abort.NewMalformedError(name, "z2 is not in Z*_N0 or z3 is negative")
+	}
+
+	// range check
+	if !prime.IsInInterval(pf.Z1, bounds.lEps) {
+		return abort.NewRejectedError(name, "range check", "z1 is out of range")
+	}
+
+	e := logStarChallenge(q, pk, NCap, s, t, C, X, G, pf.S, pf.A, pf.Y, pf.D, cmt.OptionalSession(optionalSession))
+
+	{ // (1+N0)^z1 * z2^N0 = A * C^e mod N0^2
+		modN0Squared := prime.ModInt(N0Squared)
+		left := modN0Squared.Mul(modN0Squared.Exp(pk.Gamma(), pf.Z1), modN0Squared.Exp(pf.Z2, N0))
+		right := modN0Squared.Mul(pf.A, modN0Squared.Exp(C, e))
+		if left.Cmp(right) != 0 {
+			return abort.NewRejectedError(name, "equality check 1", "(1+N0)^z1 * z2^N0 != A * C^e mod N0^2")
+		}
+	}
+
+	{ // G^z1 = Y * X^e
+		left := G.ScalarMult(new(big.Int).Mod(pf.Z1, q))
+		XE := X.ScalarMult(e)
+		if left == nil || XE == nil {
+			return abort.NewRejectedError(name, "equality check 2", "G^z1 or X^e is the identity")
+		}
+		right, err := XE.Add(pf.Y)
+		if err != nil || !left.Equals(right) {
+			return abort.NewRejectedError(name, "equality check 2", "G^z1 != Y * X^e")
+		}
+	}
+
+	{ // s^z1 * t^z3 = D * S^e mod NCap
+		modNCap := prime.ModInt(NCap)
+		left := modNCap.Mul(modNCap.Exp(s, pf.Z1), modNCap.Exp(t, pf.Z3))
+		right := modNCap.Mul(pf.D, modNCap.Exp(pf.S, e))
+		if left.Cmp(right) != 0 {
+			return abort.NewRejectedError(name, "equality check 3", "s^z1 * t^z3 != D * S^e mod NCap")
+		}
+	}
+	return nil
+}
+
+func (pf *ProofLogStar) ValidateBasic() bool {
+	return pf.S != nil &&
+		pf.A != nil &&
+		pf.Y.ValidateBasic() &&
+		pf.D != nil &&
+		pf.Z1 != nil &&
+		pf.Z2 != nil &&
+		pf.Z3 != nil
+}
+
+func (pf *ProofLogStar) Bytes() [ProofLogStarBytesParts][]byte {
+	var out [ProofLogStarBytesParts][]byte
+	for i, part := range pf.Flat() {
+		out[i] = part.Bytes()
+	}
+	return out
+}
+
+// ----- utils
+
+func logStarBase(ec elliptic.Curve, G *curve.ECPoint) *curve.ECPoint {
+	if G != nil {
+		return G
+	}
+	return curve.NewECPointNoCurveCheck(ec, ec.Params().Gx, ec.Params().Gy)
+}
+
+// logStarChallenge derives e, binding the base G along with the statement
+func logStarChallenge(q *big.Int, pk *paillier.PublicKey, NCap, s, t, C *big.Int, X, G *curve.ECPoint,
+	S, A *big.Int, Y *curve.ECPoint, D *big.Int, session *cmt.Session) *big.Int {
+	ch := &cmt.Challenge{
+		Domain:     "zk-proof/mta/logstar/v1",
+		Statement:  append(pk.AsInts(), NCap, s, t, C, X.X(), X.Y(), G.X(), G.Y()),
+		Commitment: []*big.Int{S, A, Y.X(), Y.Y(), D},
+	}
+	return ch.Scalar(session, q)
+}
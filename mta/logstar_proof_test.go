@@ -0,0 +1,113 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package mta
+
+import (
+	"crypto/elliptic"
+	"github.com/decred/dcrd/dcrec/edwards"
+	"github.com/zhp12543/zk-proof/abort"
+	"github.com/zhp12543/zk-proof/curve"
+	"math/big"
+	"testing"
+)
+
+func TestProofLogStar(t *testing.T) {
+	ec := elliptic.P256()
+	q := ec.Params().N
+	alice, bob := setUp(t)
+
+	// Alice proves to Bob that C = Enc_A(x) and X = g^x, using Bob's ring-Pedersen parameters
+	x := curve.GetRandomPositiveInt(q)
+	X := curve.ScalarBaseMult(ec, x)
+	C, rho, err := alice.pk.EncryptAndReturnRandomness(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pf, err := ProveLogStar(ec, alice.pk, bob.NTilde, bob.h1, bob.h2, C, X, nil, x, rho)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pf.Verify(ec, alice.pk, bob.NTilde, bob.h1, bob.h2, C, X, nil) {
+		t.Fatal("ProofLogStar did not verify")
+	}
+	bzs := pf.Bytes()
+	pf2, err := ProofLogStarFromBytes(ec, bzs[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pf2.Verify(ec, alice.pk, bob.NTilde, bob.h1, bob.h2, C, X, nil) {
+		t.Fatal("ProofLogStar did not verify after a bytes round trip")
+	}
+
+	// with an arbitrary base G
+	G := curve.ScalarBaseMult(ec, curve.GetRandomPositiveInt(q))
+	GX := G.ScalarMult(x)
+	pf3, err := ProveLogStar(ec, alice.pk, bob.NTilde, bob.h1, bob.h2, C, GX, G, x, rho)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pf3.Verify(ec, alice.pk, bob.NTilde, bob.h1, bob.h2, C, GX, G) {
+		t.Fatal("ProofLogStar did not verify with an arbitrary base")
+	}
+	if pf3.Verify(ec, alice.pk, bob.NTilde, bob.h1, bob.h2, C, X, G) {
+		t.Error("ProofLogStar verified against the wrong point")
+	}
+
+	offCurve := curve.NewECPointNoCurveCheck(ec, big.NewInt(1), big.NewInt(1))
+	if pf.Verify(ec, alice.pk, bob.NTilde, bob.h1, bob.h2, C, offCurve, nil) {
+		t.Error("ProofLogStar verified for an off-curve point")
+	}
+	// (0, 0) is off P256, so the identity is checked on the edwards curve, where (0, 1) passes ValidateBasic.
+	// The proof for x = 0 is otherwise valid, so only the identity check can reject it.
+	ed := edwards.Edwards()
+	identity, err := curve.NewECPoint(ed, big.NewInt(0), big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	CEd, rhoEd, err := alice.pk.EncryptAndReturnRandomness(big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pfEd, err := ProveLogStar(ed, alice.pk, bob.NTilde, bob.h1, bob.h2, CEd, identity, nil, big.NewInt(0), rhoEd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pfEd.Verify(ed, alice.pk, bob.NTilde, bob.h1, bob.h2, CEd, identity, nil) {
+		t.Error("ProofLogStar verified for the identity point")
+	}
+	C2, err := alice.pk.Encrypt(new(big.Int).Add(x, one))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pf.Verify(ec, alice.pk, bob.NTilde, bob.h1, bob.h2, C2, X, nil) {
+		t.Error("ProofLogStar verified for a ciphertext of a different x")
+	}
+
+	verify := func(pf *ProofLogStar) error {
+		return pf.VerifyWithReason(ec, alice.pk, bob.NTilde, bob.h1, bob.h2, C, X, nil)
+	}
+	expectReason(t, "off-curve X", pf.VerifyWithReason(ec, alice.pk, bob.NTilde, bob.h1, bob.h2, C, offCurve, nil), abort.Malformed, "")
+	bad := *pf
+	bad.Z2 = new(big.Int).Add(pf.Z2, one)
+	expectReason(t, "z2 changed", verify(&bad), abort.Rejected, "equality check 1")
+	bad = *pf
+	bad.Z3 = new(big.Int).Add(pf.Z3, one)
+	expectReason(t, "z3 changed", verify(&bad), abort.Rejected, "equality check 3")
+
+	session, other := testSessions()
+	pf, err = ProveLogStar(ec, alice.pk, bob.NTilde, bob.h1, bob.h2, C, X, nil, x, rho, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pf.Verify(ec, alice.pk, bob.NTilde, bob.h1, bob.h2, C, X, nil, session) {
+		t.Fatal("ProofLogStar did not verify in its session")
+	}
+	if pf.Verify(ec, alice.pk, bob.NTilde, bob.h1, bob.h2, C, X, nil, other) || pf.Verify(ec, alice.pk, bob.NTilde, bob.h1, bob.h2, C, X, nil) {
+		t.Error("ProofLogStar verified outside its session")
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package mta
+
+import (
+	"context"
+	"errors"
+	"github.com/zhp12543/zk-proof/abort"
+	"github.com/zhp12543/zk-proof/cmt"
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/paillier"
+	"github.com/zhp12543/zk-proof/prime"
+	"math/big"
+	"testing"
+	"time"
+)
+
+const (
+	// smaller than the production sizes to keep the tests fast; the proofs do not depend on them
+	testPaillierKeyLength = 1024
+	testSafePrimeBitLen   = 512
+)
+
+type testParty struct {
+	sk             *paillier.PrivateKey
+	pk             *paillier.PublicKey
+	NTilde, h1, h2 *big.Int
+}
+
+var testParties []*testParty
+
+func setUp(t *testing.T) (alice, bob *testParty) {
+	for len(testParties) < 2 {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		sk, pk, err := paillier.GenerateKeyPair(ctx, testPaillierKeyLength)
+		if err != nil {
+			cancel()
+			t.Fatal(err)
+		}
+		sgps, err := prime.GetRandomSafePrimesConcurrent(ctx, testSafePrimeBitLen, 2, 1)
+		cancel()
+		if err != nil {
+			t.Fatal(err)
+		}
+		NTilde := new(big.Int).Mul(sgps[0].SafePrime(), sgps[1].SafePrime())
+		f := curve.GetRandomPositiveRelativelyPrimeInt(NTilde)
+		h1 := prime.ModInt(NTilde).Mul(f, f)
+		h2 := prime.ModInt(NTilde).Exp(h1, curve.GetRandomPositiveRelativelyPrimeInt(NTilde))
+		testParties = append(testParties, &testParty{sk: sk, pk: pk, NTilde: NTilde, h1: h1, h2: h2})
+	}
+	return testParties[0], testParties[1]
+}
+
+// expectReason fails the test unless err is an *abort.VerifyError of the given kind and step
+func expectReason(t *testing.T, what string, err error, kind abort.ReasonKind, step string) {
+	t.Helper()
+	var ve *abort.VerifyError
+	if !errors.As(err, &ve) || ve.Kind != kind || ve.Step != step {
+		t.Errorf("%s: unexpected reason %v", what, err)
+	}
+}
+
+// testSessions returns a session and one of another party in the same protocol execution
+func testSessions() (session, other *cmt.Session) {
+	session = &cmt.Session{ID: []byte("session"), Party: big.NewInt(1)}
+	other = &cmt.Session{ID: []byte("session"), Party: big.NewInt(2)}
+	return
+}
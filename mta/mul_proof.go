@@ -0,0 +1,152 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package mta
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"github.com/zhp12543/zk-proof/abort"
+	"github.com/zhp12543/zk-proof/cmt"
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/paillier"
+	"github.com/zhp12543/zk-proof/prime"
+	"math/big"
+)
+
+const (
+	ProofMulBytesParts = 5
+)
+
+type (
+	// ProofMul is the Paillier multiplication proof Πmul from CGGMP21 Fig. 29
+	ProofMul struct {
+		A, B, Z, U, V *big.Int
+	}
+)
+
+// MulAndReturnRandomness computes C = Y^x * rho^N mod N2, an encryption of x * Dec(Y), for use with ProveMul
+func MulAndReturnRandomness(pk *paillier.PublicKey, Y, x *big.Int) (C, rho *big.Int, err error) {
+	C, err = pk.HomoMult(x, Y)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pk.Rerandomize(C)
+}
+
+// ProveMul proves that C = Y^x * rho^N mod N2 where X = Enc(x; rhoX), i.e. C encrypts the product of the plaintexts of X and Y.
+func ProveMul(ec elliptic.Curve, pk *paillier.PublicKey, X, Y, C, x, rhoX, rho *big.Int, optionalSession ...*cmt.Session) (*ProofMul, error) {
+	if ec == nil || pk == nil || X == nil || Y == nil || C == nil || x == nil || rhoX == nil || rho == nil {
+		return nil, errors.New("ProveMul() received a nil argument")
+	}
+	q := ec.Params().N
+	N := pk.N
+	// alpha statistically hides e * x with e < q and x < N
+	alphaBound := new(big.Int).Lsh(one, uint(N.BitLen()+2*q.BitLen()))
+
+	// 1.
+	alpha := curve.GetRandomPositiveInt(alphaBound)
+	r := curve.GetRandomPositiveRelativelyPrimeInt(N)
+	s := curve.GetRandomPositiveRelativelyPrimeInt(N)
+
+	// 2. A = Y^alpha * r^N, B = (1+N)^alpha * s^N mod N2
+	modNSquared := prime.ModInt(pk.NSquare())
+	A := modNSquared.Mul(modNSquared.Exp(Y, alpha), modNSquared.Exp(r, N))
+	B := modNSquared.Mul(modNSquared.Exp(pk.Gamma(), alpha), modNSquared.Exp(s, N))
+
+	// 3. e'
+	e := mulChallenge(q, pk, X, Y, C, A, B, cmt.OptionalSession(optionalSession))
+
+	// 4.
+	modN := prime.ModInt(N)
+	z := new(big.Int).Mul(e, x)
+	z = z.Add(z, alpha)
+	u := modN.Mul(r, modN.Exp(rho, e))
+	v := modN.Mul(s, modN.Exp(rhoX, e))
+	return &ProofMul{A: A, B: B, Z: z, U: u, V: v}, nil
+}
+
+func ProofMulFromBytes(bzs [][]byte) (*ProofMul, error) {
+	if !curve.NonEmptyMultiBytes(bzs, ProofMulBytesParts) {
+		return nil, fmt.Errorf("expected %d byte parts to construct ProofMul", ProofMulBytesParts)
+	}
+	return ProofMulUnFlat(curve.MultiBytesToBigInts(bzs))
+}
+
+func (pf *ProofMul) Verify(ec elliptic.Curve, pk *paillier.PublicKey, X, Y, C *big.Int, optionalSession ...*cmt.Session) bool {
+	return pf.VerifyWithReason(ec, pk, X, Y, C, optionalSession...) == nil
+}
+
+// VerifyWithReason is Verify returning an *abort.VerifyError that names the failed step of CGGMP21 Fig. 29
+func (pf *ProofMul) VerifyWithReason(ec elliptic.Curve, pk *paillier.PublicKey, X, Y, C *big.Int, optionalSession ...*cmt.Session) error {
+	const name = "ProofMul"
+	if pf == nil || !pf.ValidateBasic() || ec == nil || pk == nil || X == nil || Y == nil || C == nil {
+		return abort.NewMalformedError(name, "nil proof or statement value(s)")
+	}
+	q := ec.Params().N
+	N, NSquared := pk.N, pk.NSquare()
+
+	for _, v := range []*big.Int{X, Y, C, pf.A, pf.B} {
+		if !curve.IsNumberInMultiplicativeGroup(NSquared, v) {
+			return abort.NewMalformedError(name, "X, Y, C, A or B is not in Z*_N^2")
+		}
+	}
+	if !curve.IsNumberInMultiplicativeGroup(N, pf.U) || !curve.IsNumberInMultiplicativeGroup(N, pf.V) {
+		return abort.NewMalformedError(name, "u or v is not in Z*_N")
+	}
+
+	// 1-2. e'
+	e := mulChallenge(q, pk, X, Y, C, pf.A, pf.B, cmt.OptionalSession(optionalSession))
+
+	modNSquared := prime.ModInt(NSquared)
+	{ // 3. Y^z * u^N = A * C^e mod N2
+		left := modNSquared.Mul(modNSquared.Exp(Y, pf.Z), modNSquared.Exp(pf.U, N))
+		right := modNSquared.Mul(pf.A, modNSquared.Exp(C, e))
+		if left.Cmp(right) != 0 {
+			return abort.NewRejectedError(name, "3", "Y^z * u^N != A * C^e mod N^2")
+		}
+	}
+
+	{ // 4. (1+N)^z * v^N = B * X^e mod N2
+		left := modNSquared.Mul(modNSquared.Exp(pk.Gamma(), pf.Z), modNSquared.Exp(pf.V, N))
+		right := modNSquared.Mul(pf.B, modNSquared.Exp(X, e))
+		if left.Cmp(right) != 0 {
+			return abort.NewRejectedError(name, "4", "(1+N)^z * v^N != B * X^e mod N^2")
+		}
+	}
+	return nil
+}
+
+func (pf *ProofMul) ValidateBasic() bool {
+	return pf.A != nil &&
+		pf.B != nil &&
+		pf.Z != nil &&
+		pf.U != nil &&
+		pf.V != nil
+}
+
+func (pf *ProofMul) Bytes() [ProofMulBytesParts][]byte {
+	return [...][]byte{
+		pf.A.Bytes(),
+		pf.B.Bytes(),
+		pf.Z.Bytes(),
+		pf.U.Bytes(),
+		pf.V.Bytes(),
+	}
+}
+
+// ----- utils
+
+// mulChallenge derives e for C = Y^x * rho^N
+func mulChallenge(q *big.Int, pk *paillier.PublicKey, X, Y, C, A, B *big.Int, session *cmt.Session) *big.Int {
+	ch := &cmt.Challenge{
+		Domain:     "zk-proof/mta/mul/v1",
+		Statement:  append(pk.AsInts(), X, Y, C),
+		Commitment: []*big.Int{A, B},
+	}
+	return ch.Scalar(session, q)
+}
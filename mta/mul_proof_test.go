@@ -0,0 +1,96 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package mta
+
+import (
+	"crypto/elliptic"
+	"github.com/zhp12543/zk-proof/abort"
+	"github.com/zhp12543/zk-proof/curve"
+	"math/big"
+	"testing"
+)
+
+func TestProofMul(t *testing.T) {
+	ec := elliptic.P256()
+	q := ec.Params().N
+	alice, _ := setUp(t)
+	pk := alice.pk
+
+	x, y := curve.GetRandomPositiveInt(q), curve.GetRandomPositiveInt(q)
+	X, rhoX, err := pk.EncryptAndReturnRandomness(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Y, err := pk.Encrypt(y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	C, rho, err := MulAndReturnRandomness(pk, Y, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xy, err := alice.sk.Decrypt(C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if xy.Cmp(new(big.Int).Mul(x, y)) != 0 {
+		t.Fatal("C does not encrypt x*y")
+	}
+
+	pf, err := ProveMul(ec, pk, X, Y, C, x, rhoX, rho)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pf.Verify(ec, pk, X, Y, C) {
+		t.Fatal("ProofMul did not verify")
+	}
+	bzs := pf.Bytes()
+	pf2, err := ProofMulFromBytes(bzs[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pf2.Verify(ec, pk, X, Y, C) {
+		t.Fatal("ProofMul did not verify after a bytes round trip")
+	}
+	pf3, err := ProofMulUnFlat(pf.Flat())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pf3.Verify(ec, pk, X, Y, C) {
+		t.Fatal("ProofMul did not verify after a flat round trip")
+	}
+
+	wrongC, err := pk.Encrypt(new(big.Int).Add(xy, one))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pf.Verify(ec, pk, X, Y, wrongC) {
+		t.Error("ProofMul verified for a wrong product")
+	}
+
+	bad := *pf
+	bad.U = big.NewInt(0)
+	expectReason(t, "zero u", bad.VerifyWithReason(ec, pk, X, Y, C), abort.Malformed, "")
+	bad = *pf
+	bad.U = new(big.Int).Add(pf.U, one)
+	expectReason(t, "u changed", bad.VerifyWithReason(ec, pk, X, Y, C), abort.Rejected, "3")
+	bad = *pf
+	bad.V = new(big.Int).Add(pf.V, one)
+	expectReason(t, "v changed", bad.VerifyWithReason(ec, pk, X, Y, C), abort.Rejected, "4")
+
+	session, other := testSessions()
+	pf, err = ProveMul(ec, pk, X, Y, C, x, rhoX, rho, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pf.Verify(ec, pk, X, Y, C, session) {
+		t.Fatal("ProofMul did not verify in its session")
+	}
+	if pf.Verify(ec, pk, X, Y, C, other) || pf.Verify(ec, pk, X, Y, C) {
+		t.Error("ProofMul verified outside its session")
+	}
+}
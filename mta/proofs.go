@@ -8,12 +8,15 @@ package mta
 
 import (
 	"crypto/elliptic"
+	"crypto/rand"
 	"errors"
 	"fmt"
+	"github.com/zhp12543/zk-proof/abort"
 	"github.com/zhp12543/zk-proof/cmt"
 	"github.com/zhp12543/zk-proof/curve"
 	"github.com/zhp12543/zk-proof/paillier"
 	"github.com/zhp12543/zk-proof/prime"
+	"io"
 	"math/big"
 )
 
@@ -35,8 +38,13 @@ type (
 
 // ProveBobWC implements Bob's proof both with or without check "ProveMtawc_Bob" and "ProveMta_Bob" used in the MtA protocol from GG18Spec (9) Figs. 10 & 11.
 // an absent `X` generates the proof without the X consistency check X = g^x
-func ProveBobWC(ec elliptic.Curve, pk *paillier.PublicKey, NTilde, h1, h2, c1, c2, x, y, r *big.Int, X *curve.ECPoint) (*ProofBobWC, error) {
-	if pk == nil || NTilde == nil || h1 == nil || h2 == nil || c1 == nil || c2 == nil || x == nil || y == nil || r == nil {
+func ProveBobWC(ec elliptic.Curve, pk *paillier.PublicKey, NTilde, h1, h2, c1, c2, x, y, r *big.Int, X *curve.ECPoint, optionalSession ...*cmt.Session) (*ProofBobWC, error) {
+	return ProveBobWCWithReader(rand.Reader, ec, pk, NTilde, h1, h2, c1, c2, x, y, r, X, optionalSession...)
+}
+
+// ProveBobWCWithReader is ProveBobWC drawing its randomness from `reader`, so that proofs can be replayed in known-answer tests.
+func ProveBobWCWithReader(reader io.Reader, ec elliptic.Curve, pk *paillier.PublicKey, NTilde, h1, h2, c1, c2, x, y, r *big.Int, X *curve.ECPoint, optionalSession ...*cmt.Session) (*ProofBobWC, error) {
+	if reader == nil || pk == nil || NTilde == nil || h1 == nil || h2 == nil || c1 == nil || c2 == nil || x == nil || y == nil || r == nil {
 		return nil, errors.New("ProveBob() received a nil argument")
 	}
 
@@ -52,20 +60,41 @@ func ProveBobWC(ec elliptic.Curve, pk *paillier.PublicKey, NTilde, h1, h2, c1, c
 
 	// steps are numbered as shown in Fig. 10, but diverge slightly for Fig. 11
 	// 1.
-	alpha := curve.GetRandomPositiveInt(q3)
+	alpha, err := curve.GetRandomPositiveIntWithReader(reader, q3)
+	if err != nil {
+		return nil, err
+	}
 
 	// 2.
-	rho := curve.GetRandomPositiveInt(qNTilde)
-	sigma := curve.GetRandomPositiveInt(qNTilde)
-	tau := curve.GetRandomPositiveInt(q3NTilde)
+	rho, err := curve.GetRandomPositiveIntWithReader(reader, qNTilde)
+	if err != nil {
+		return nil, err
+	}
+	sigma, err := curve.GetRandomPositiveIntWithReader(reader, qNTilde)
+	if err != nil {
+		return nil, err
+	}
+	tau, err := curve.GetRandomPositiveIntWithReader(reader, q3NTilde)
+	if err != nil {
+		return nil, err
+	}
 
 	// 3.
-	rhoPrm := curve.GetRandomPositiveInt(q3NTilde)
+	rhoPrm, err := curve.GetRandomPositiveIntWithReader(reader, q3NTilde)
+	if err != nil {
+		return nil, err
+	}
 
 	// 4.
-	beta := curve.GetRandomPositiveRelativelyPrimeInt(pk.N)
+	beta, err := curve.GetRandomPositiveRelativelyPrimeIntWithReader(reader, pk.N)
+	if err != nil {
+		return nil, err
+	}
 
-	gamma := curve.GetRandomPositiveInt(q7)
+	gamma, err := curve.GetRandomPositiveIntWithReader(reader, q7)
+	if err != nil {
+		return nil, err
+	}
 
 	// 5.
 	u := curve.NewECPointNoCurveCheck(ec, zero, zero) // initialization suppresses an IDE warning
@@ -97,17 +126,8 @@ func ProveBobWC(ec elliptic.Curve, pk *paillier.PublicKey, NTilde, h1, h2, c1, c
 	w = modNTilde.Mul(w, modNTilde.Exp(h2, tau))
 
 	// 11-12. e'
-	var e *big.Int
-	{ // must use RejectionSample
-		var eHash *big.Int
-		// X is nil if called by ProveBob (Bob's proof "without check")
-		if X == nil {
-			eHash = cmt.SHA512_256i(append(pk.AsInts(), c1, c2, z, zPrm, t, v, w)...)
-		} else {
-			eHash = cmt.SHA512_256i(append(pk.AsInts(), X.X(), X.Y(), c1, c2, u.X(), u.Y(), z, zPrm, t, v, w)...)
-		}
-		e = cmt.RejectionSample(q, eHash)
-	}
+	// X is nil if called by ProveBob (Bob's proof "without check")
+	e := bobChallenge(q, pk, NTilde, h1, h2, c1, c2, X, u, []*big.Int{z, zPrm, t, v, w}, cmt.OptionalSession(optionalSession))
 
 	// 13.
 	modN := prime.ModInt(pk.N)
@@ -138,10 +158,15 @@ func ProveBobWC(ec elliptic.Curve, pk *paillier.PublicKey, NTilde, h1, h2, c1, c
 }
 
 // ProveBob implements Bob's proof "ProveMta_Bob" used in the MtA protocol from GG18Spec (9) Fig. 11.
-func ProveBob(ec elliptic.Curve, pk *paillier.PublicKey, NTilde, h1, h2, c1, c2, x, y, r *big.Int) (*ProofBob, error) {
+func ProveBob(ec elliptic.Curve, pk *paillier.PublicKey, NTilde, h1, h2, c1, c2, x, y, r *big.Int, optionalSession ...*cmt.Session) (*ProofBob, error) {
+	return ProveBobWithReader(rand.Reader, ec, pk, NTilde, h1, h2, c1, c2, x, y, r, optionalSession...)
+}
+
+// ProveBobWithReader is ProveBob drawing its randomness from `reader`.
+func ProveBobWithReader(reader io.Reader, ec elliptic.Curve, pk *paillier.PublicKey, NTilde, h1, h2, c1, c2, x, y, r *big.Int, optionalSession ...*cmt.Session) (*ProofBob, error) {
 	// the Bob proof ("with check") contains the ProofBob "without check"; this method extracts and returns it
 	// X is supplied as nil to exclude it from the proof hash
-	pf, err := ProveBobWC(ec, pk, NTilde, h1, h2, c1, c2, x, y, r, nil)
+	pf, err := ProveBobWCWithReader(reader, ec, pk, NTilde, h1, h2, c1, c2, x, y, r, nil, optionalSession...)
 	if err != nil {
 		return nil, err
 	}
@@ -188,9 +213,19 @@ func ProofBobFromBytes(bzs [][]byte) (*ProofBob, error) {
 
 // ProveBobWC.Verify implements verification of Bob's proof with check "VerifyMtawc_Bob" used in the MtA protocol from GG18Spec (9) Fig. 10.
 // an absent `X` verifies a proof generated without the X consistency check X = g^x
-func (pf *ProofBobWC) Verify(ec elliptic.Curve, pk *paillier.PublicKey, NTilde, h1, h2, c1, c2 *big.Int, X *curve.ECPoint) bool {
-	if pk == nil || NTilde == nil || h1 == nil || h2 == nil || c1 == nil || c2 == nil {
-		return false
+func (pf *ProofBobWC) Verify(ec elliptic.Curve, pk *paillier.PublicKey, NTilde, h1, h2, c1, c2 *big.Int, X *curve.ECPoint, optionalSession ...*cmt.Session) bool {
+	return pf.VerifyWithReason(ec, pk, NTilde, h1, h2, c1, c2, X, optionalSession...) == nil
+}
+
+// VerifyWithReason is Verify returning an *abort.VerifyError that names the failed step of Fig. 10 (Fig. 11 if X is absent)
+func (pf *ProofBobWC) VerifyWithReason(ec elliptic.Curve, pk *paillier.PublicKey, NTilde, h1, h2, c1, c2 *big.Int, X *curve.ECPoint, optionalSession ...*cmt.Session) error {
+	name := "ProofBobWC"
+	if X == nil {
+		name = "ProofBob"
+	}
+	if pf == nil || pf.ProofBob == nil || !pf.ProofBob.ValidateBasic() || (X != nil && pf.U == nil) ||
+		pk == nil || NTilde == nil || h1 == nil || h2 == nil || c1 == nil || c2 == nil {
+		return abort.NewMalformedError(name, "nil proof or statement value(s)")
 	}
 
 	q := ec.Params().N
@@ -200,73 +235,64 @@ func (pf *ProofBobWC) Verify(ec elliptic.Curve, pk *paillier.PublicKey, NTilde,
 	q7 = new(big.Int).Mul(q7, q)   // q^7
 
 	if !prime.IsInInterval(pf.Z, NTilde) {
-		return false
+		return abort.NewMalformedError(name, "z is not in [0, NTilde)")
 	}
 	if !prime.IsInInterval(pf.ZPrm, NTilde) {
-		return false
+		return abort.NewMalformedError(name, "z' is not in [0, NTilde)")
 	}
 	if !prime.IsInInterval(pf.T, NTilde) {
-		return false
+		return abort.NewMalformedError(name, "t is not in [0, NTilde)")
 	}
 	if !prime.IsInInterval(pf.V, pk.NSquare()) {
-		return false
+		return abort.NewMalformedError(name, "v is not in [0, N^2)")
 	}
 	if !prime.IsInInterval(pf.W, NTilde) {
-		return false
+		return abort.NewMalformedError(name, "w is not in [0, NTilde)")
 	}
 	if !prime.IsInInterval(pf.S, pk.N) {
-		return false
+		return abort.NewMalformedError(name, "s is not in [0, N)")
 	}
 	if new(big.Int).GCD(nil, nil, pf.Z, NTilde).Cmp(one) != 0 {
-		return false
+		return abort.NewMalformedError(name, "z is not coprime to NTilde")
 	}
 	if new(big.Int).GCD(nil, nil, pf.ZPrm, NTilde).Cmp(one) != 0 {
-		return false
+		return abort.NewMalformedError(name, "z' is not coprime to NTilde")
 	}
 	if new(big.Int).GCD(nil, nil, pf.T, NTilde).Cmp(one) != 0 {
-		return false
+		return abort.NewMalformedError(name, "t is not coprime to NTilde")
 	}
 	if new(big.Int).GCD(nil, nil, pf.V, pk.NSquare()).Cmp(one) != 0 {
-		return false
+		return abort.NewMalformedError(name, "v is not coprime to N^2")
 	}
 	if new(big.Int).GCD(nil, nil, pf.W, NTilde).Cmp(one) != 0 {
-		return false
+		return abort.NewMalformedError(name, "w is not coprime to NTilde")
 	}
 
 	gcd := big.NewInt(0)
 	if pf.S.Cmp(zero) == 0 {
-		return false
+		return abort.NewMalformedError(name, "s is zero")
 	}
 	if gcd.GCD(nil, nil, pf.S, pk.N).Cmp(one) != 0 {
-		return false
+		return abort.NewMalformedError(name, "s is not coprime to N")
 	}
 	if pf.V.Cmp(zero) == 0 {
-		return false
+		return abort.NewMalformedError(name, "v is zero")
 	}
 	if gcd.GCD(nil, nil, pf.V, pk.N).Cmp(one) != 0 {
-		return false
+		return abort.NewMalformedError(name, "v is not coprime to N")
 	}
 
 	// 3.
 	if pf.S1.Cmp(q3) > 0 {
-		return false
+		return abort.NewRejectedError(name, "3", "s1 > q^3")
 	}
 	if pf.T1.Cmp(q7) > 0 {
-		return false
+		return abort.NewRejectedError(name, "3", "t1 > q^7")
 	}
 
 	// 1-2. e'
-	var e *big.Int
-	{ // must use RejectionSample
-		var eHash *big.Int
-		// X is nil if called on a ProveBob (Bob's proof "without check")
-		if X == nil {
-			eHash = cmt.SHA512_256i(append(pk.AsInts(), c1, c2, pf.Z, pf.ZPrm, pf.T, pf.V, pf.W)...)
-		} else {
-			eHash = cmt.SHA512_256i(append(pk.AsInts(), X.X(), X.Y(), c1, c2, pf.U.X(), pf.U.Y(), pf.Z, pf.ZPrm, pf.T, pf.V, pf.W)...)
-		}
-		e = cmt.RejectionSample(q, eHash)
-	}
+	// X is nil if called on a ProveBob (Bob's proof "without check")
+	e := bobChallenge(q, pk, NTilde, h1, h2, c1, c2, X, pf.U, []*big.Int{pf.Z, pf.ZPrm, pf.T, pf.V, pf.W}, cmt.OptionalSession(optionalSession))
 
 	var left, right *big.Int // for the following conditionals
 
@@ -276,7 +302,7 @@ func (pf *ProofBobWC) Verify(ec elliptic.Curve, pk *paillier.PublicKey, NTilde,
 		gS1 := curve.ScalarBaseMult(ec, s1ModQ)
 		xEU, err := X.ScalarMult(e).Add(pf.U)
 		if err != nil || !gS1.Equals(xEU) {
-			return false
+			return abort.NewRejectedError(name, "4", "g^s1 != X^e * u")
 		}
 	}
 
@@ -290,7 +316,7 @@ func (pf *ProofBobWC) Verify(ec elliptic.Curve, pk *paillier.PublicKey, NTilde,
 			zExpE := modNTilde.Exp(pf.Z, e)
 			right = modNTilde.Mul(zExpE, pf.ZPrm)
 			if left.Cmp(right) != 0 {
-				return false
+				return abort.NewRejectedError(name, "5", "h1^s1 * h2^s2 != z^e * z' mod NTilde")
 			}
 		}
 
@@ -301,7 +327,7 @@ func (pf *ProofBobWC) Verify(ec elliptic.Curve, pk *paillier.PublicKey, NTilde,
 			tExpE := modNTilde.Exp(pf.T, e)
 			right = modNTilde.Mul(tExpE, pf.W)
 			if left.Cmp(right) != 0 {
-				return false
+				return abort.NewRejectedError(name, "6", "h1^t1 * h2^t2 != t^e * w mod NTilde")
 			}
 		}
 	}
@@ -317,19 +343,24 @@ func (pf *ProofBobWC) Verify(ec elliptic.Curve, pk *paillier.PublicKey, NTilde,
 		c2ExpE := modNSquared.Exp(c2, e)
 		right = modNSquared.Mul(c2ExpE, pf.V)
 		if left.Cmp(right) != 0 {
-			return false
+			return abort.NewRejectedError(name, "7", "c1^s1 * s^N * gamma^t1 != c2^e * v mod N^2")
 		}
 	}
-	return true
+	return nil
 }
 
 // ProveBob.Verify implements verification of Bob's proof without check "VerifyMta_Bob" used in the MtA protocol from GG18Spec (9) Fig. 11.
-func (pf *ProofBob) Verify(ec elliptic.Curve, pk *paillier.PublicKey, NTilde, h1, h2, c1, c2 *big.Int) bool {
+func (pf *ProofBob) Verify(ec elliptic.Curve, pk *paillier.PublicKey, NTilde, h1, h2, c1, c2 *big.Int, optionalSession ...*cmt.Session) bool {
+	return pf.VerifyWithReason(ec, pk, NTilde, h1, h2, c1, c2, optionalSession...) == nil
+}
+
+// VerifyWithReason is Verify returning an *abort.VerifyError that names the failed step of Fig. 11
+func (pf *ProofBob) VerifyWithReason(ec elliptic.Curve, pk *paillier.PublicKey, NTilde, h1, h2, c1, c2 *big.Int, optionalSession ...*cmt.Session) error {
 	if pf == nil {
-		return false
+		return abort.NewMalformedError("ProofBob", "nil proof")
 	}
 	pfWC := &ProofBobWC{ProofBob: pf, U: nil}
-	return pfWC.Verify(ec, pk, NTilde, h1, h2, c1, c2, nil)
+	return pfWC.VerifyWithReason(ec, pk, NTilde, h1, h2, c1, c2, nil, optionalSession...)
 }
 
 func (pf *ProofBob) ValidateBasic() bool {
@@ -373,3 +404,23 @@ func (pf *ProofBobWC) Bytes() [ProofBobWCBytesParts][]byte {
 	copy(out[:], bobBzsSlice[:12])
 	return out
 }
+
+// ----- utils
+
+// bobChallenge derives e. `commitments` are z, z', t, v, w; X and U are nil for the proof without check.
+// The hash without a session never covered NTilde, h1 and h2.
+func bobChallenge(q *big.Int, pk *paillier.PublicKey, NTilde, h1, h2, c1, c2 *big.Int, X, U *curve.ECPoint, commitments []*big.Int, session *cmt.Session) *big.Int {
+	ch := &cmt.Challenge{
+		Domain:     "zk-proof/mta/proof-bob/v1",
+		Statement:  append(pk.AsInts(), NTilde, h1, h2, c1, c2),
+		Commitment: commitments,
+		Legacy:     append(pk.AsInts(), c1, c2),
+	}
+	if X != nil {
+		ch.Domain = "zk-proof/mta/proof-bob-wc/v1"
+		ch.Statement = append(ch.Statement, X.X(), X.Y(), U.X(), U.Y())
+		ch.Legacy = append(pk.AsInts(), X.X(), X.Y(), c1, c2, U.X(), U.Y())
+	}
+	ch.Legacy = append(ch.Legacy, commitments...)
+	return ch.Scalar(session, q)
+}
@@ -0,0 +1,92 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package mta
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"github.com/zhp12543/zk-proof/abort"
+	"github.com/zhp12543/zk-proof/curve"
+	"math/big"
+	"testing"
+)
+
+func TestProofBobVerifyWithReason(t *testing.T) {
+	ec := elliptic.P256()
+	q := ec.Params().N
+	alice, _ := setUp(t)
+	cA, err := alice.pk.Encrypt(curve.GetRandomPositiveInt(q))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// c2 = x * cA + Enc(y); the test keys are too small for the q^5 mask of BobMid, so y < N
+	x := curve.GetRandomPositiveInt(q)
+	X := curve.ScalarBaseMult(ec, x)
+	y := curve.GetRandomPositiveInt(alice.pk.N)
+	cY, r, err := alice.pk.EncryptAndReturnRandomness(y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := alice.pk.HomoMult(x, cA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c2, err = alice.pk.HomoAdd(c2, cY); err != nil {
+		t.Fatal(err)
+	}
+	pfWC, err := ProveBobWC(ec, alice.pk, alice.NTilde, alice.h1, alice.h2, cA, c2, x, y, r, X)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pf, err := ProveBob(ec, alice.pk, alice.NTilde, alice.h1, alice.h2, cA, c2, x, y, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = pfWC.VerifyWithReason(ec, alice.pk, alice.NTilde, alice.h1, alice.h2, cA, c2, X); err != nil {
+		t.Fatalf("valid ProofBobWC was rejected: %v", err)
+	}
+	if err = pf.VerifyWithReason(ec, alice.pk, alice.NTilde, alice.h1, alice.h2, cA, c2); err != nil {
+		t.Fatalf("valid ProofBob was rejected: %v", err)
+	}
+
+	q3 := new(big.Int).Exp(q, big.NewInt(3), nil)
+	add1 := func(x *big.Int) *big.Int { return new(big.Int).Add(x, big.NewInt(1)) }
+	tests := []struct {
+		name   string
+		wc     bool
+		tamper func(pf *ProofBobWC)
+		kind   abort.ReasonKind
+		step   string
+	}{
+		{"z not coprime", true, func(pf *ProofBobWC) { pf.Z = big.NewInt(0) }, abort.Malformed, ""},
+		{"s1 too large", true, func(pf *ProofBobWC) { pf.S1 = add1(q3) }, abort.Rejected, "3"},
+		{"u changed", true, func(pf *ProofBobWC) { pf.U = curve.ScalarBaseMult(ec, big.NewInt(1)) }, abort.Rejected, "4"},
+		{"s2 changed", true, func(pf *ProofBobWC) { pf.S2 = add1(pf.S2) }, abort.Rejected, "5"},
+		{"t2 changed", true, func(pf *ProofBobWC) { pf.T2 = add1(pf.T2) }, abort.Rejected, "6"},
+		{"s changed", true, func(pf *ProofBobWC) { pf.S = add1(pf.S) }, abort.Rejected, "7"},
+		{"s2 changed", false, func(pf *ProofBobWC) { pf.S2 = add1(pf.S2) }, abort.Rejected, "5"},
+		{"t2 changed", false, func(pf *ProofBobWC) { pf.T2 = add1(pf.T2) }, abort.Rejected, "6"},
+		{"s changed", false, func(pf *ProofBobWC) { pf.S = add1(pf.S) }, abort.Rejected, "7"},
+	}
+	for _, tt := range tests {
+		var name string
+		if tt.wc {
+			copied := *pfWC.ProofBob
+			bad := &ProofBobWC{ProofBob: &copied, U: pfWC.U}
+			tt.tamper(bad)
+			name, err = "ProofBobWC", bad.VerifyWithReason(ec, alice.pk, alice.NTilde, alice.h1, alice.h2, cA, c2, X)
+		} else {
+			copied := *pf
+			tt.tamper(&ProofBobWC{ProofBob: &copied})
+			name, err = "ProofBob", copied.VerifyWithReason(ec, alice.pk, alice.NTilde, alice.h1, alice.h2, cA, c2)
+		}
+		expectReason(t, name+" "+tt.name, err, tt.kind, tt.step)
+		if ve := (*abort.VerifyError)(nil); errors.As(err, &ve) && ve.Proof != name {
+			t.Errorf("%s %s: reported for %s", name, tt.name, ve.Proof)
+		}
+	}
+}
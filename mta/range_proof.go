@@ -8,12 +8,15 @@ package mta
 
 import (
 	"crypto/elliptic"
+	"crypto/rand"
 	"errors"
 	"fmt"
+	"github.com/zhp12543/zk-proof/abort"
 	"github.com/zhp12543/zk-proof/cmt"
 	"github.com/zhp12543/zk-proof/curve"
 	"github.com/zhp12543/zk-proof/paillier"
 	"github.com/zhp12543/zk-proof/prime"
+	"io"
 	"math/big"
 )
 
@@ -33,8 +36,13 @@ type (
 )
 
 // ProveRangeAlice implements Alice's range proof used in the MtA and MtAwc protocols from GG18Spec (9) Fig. 9.
-func ProveRangeAlice(ec elliptic.Curve, pk *paillier.PublicKey, c, NTilde, h1, h2, m, r *big.Int) (*RangeProofAlice, error) {
-	if pk == nil || NTilde == nil || h1 == nil || h2 == nil || c == nil || m == nil || r == nil {
+func ProveRangeAlice(ec elliptic.Curve, pk *paillier.PublicKey, c, NTilde, h1, h2, m, r *big.Int, optionalSession ...*cmt.Session) (*RangeProofAlice, error) {
+	return ProveRangeAliceWithReader(rand.Reader, ec, pk, c, NTilde, h1, h2, m, r, optionalSession...)
+}
+
+// ProveRangeAliceWithReader is ProveRangeAlice drawing its randomness from `reader`, so that proofs can be replayed in known-answer tests.
+func ProveRangeAliceWithReader(reader io.Reader, ec elliptic.Curve, pk *paillier.PublicKey, c, NTilde, h1, h2, m, r *big.Int, optionalSession ...*cmt.Session) (*RangeProofAlice, error) {
+	if reader == nil || pk == nil || NTilde == nil || h1 == nil || h2 == nil || c == nil || m == nil || r == nil {
 		return nil, errors.New("ProveRangeAlice constructor received nil value(s)")
 	}
 
@@ -45,15 +53,27 @@ func ProveRangeAlice(ec elliptic.Curve, pk *paillier.PublicKey, c, NTilde, h1, h
 	q3NTilde := new(big.Int).Mul(q3, NTilde)
 
 	// 1.
-	alpha := curve.GetRandomPositiveInt(q3)
+	alpha, err := curve.GetRandomPositiveIntWithReader(reader, q3)
+	if err != nil {
+		return nil, err
+	}
 	// 2.
-	beta := curve.GetRandomPositiveRelativelyPrimeInt(pk.N)
+	beta, err := curve.GetRandomPositiveRelativelyPrimeIntWithReader(reader, pk.N)
+	if err != nil {
+		return nil, err
+	}
 
 	// 3.
-	gamma := curve.GetRandomPositiveInt(q3NTilde)
+	gamma, err := curve.GetRandomPositiveIntWithReader(reader, q3NTilde)
+	if err != nil {
+		return nil, err
+	}
 
 	// 4.
-	rho := curve.GetRandomPositiveInt(qNTilde)
+	rho, err := curve.GetRandomPositiveIntWithReader(reader, qNTilde)
+	if err != nil {
+		return nil, err
+	}
 
 	// 5.
 	modNTilde := prime.ModInt(NTilde)
@@ -70,11 +90,7 @@ func ProveRangeAlice(ec elliptic.Curve, pk *paillier.PublicKey, c, NTilde, h1, h
 	w = modNTilde.Mul(w, modNTilde.Exp(h2, gamma))
 
 	// 8-9. e'
-	var e *big.Int
-	{ // must use RejectionSample
-		eHash := cmt.SHA512_256i(append(pk.AsInts(), c, z, u, w)...)
-		e = cmt.RejectionSample(q, eHash)
-	}
+	e := rangeProofAliceChallenge(q, pk, NTilde, h1, h2, c, z, u, w, cmt.OptionalSession(optionalSession))
 
 	modN := prime.ModInt(pk.N)
 	s := modN.Exp(r, e)
@@ -105,9 +121,15 @@ func RangeProofAliceFromBytes(bzs [][]byte) (*RangeProofAlice, error) {
 	}, nil
 }
 
-func (pf *RangeProofAlice) Verify(ec elliptic.Curve, pk *paillier.PublicKey, NTilde, h1, h2, c *big.Int) bool {
+func (pf *RangeProofAlice) Verify(ec elliptic.Curve, pk *paillier.PublicKey, NTilde, h1, h2, c *big.Int, optionalSession ...*cmt.Session) bool {
+	return pf.VerifyWithReason(ec, pk, NTilde, h1, h2, c, optionalSession...) == nil
+}
+
+// VerifyWithReason is Verify returning an *abort.VerifyError that names the failed step of GG18Spec (9) Fig. 9
+func (pf *RangeProofAlice) VerifyWithReason(ec elliptic.Curve, pk *paillier.PublicKey, NTilde, h1, h2, c *big.Int, optionalSession ...*cmt.Session) error {
+	const name = "RangeProofAlice"
 	if pf == nil || !pf.ValidateBasic() || pk == nil || NTilde == nil || h1 == nil || h2 == nil || c == nil {
-		return false
+		return abort.NewMalformedError(name, "nil proof or statement value(s)")
 	}
 
 	q := ec.Params().N
@@ -115,38 +137,34 @@ func (pf *RangeProofAlice) Verify(ec elliptic.Curve, pk *paillier.PublicKey, NTi
 	q3 = new(big.Int).Mul(q, q3)
 
 	if !prime.IsInInterval(pf.Z, NTilde) {
-		return false
+		return abort.NewMalformedError(name, "z is not in [0, NTilde)")
 	}
 	if !prime.IsInInterval(pf.U, pk.NSquare()) {
-		return false
+		return abort.NewMalformedError(name, "u is not in [0, N^2)")
 	}
 	if !prime.IsInInterval(pf.W, NTilde) {
-		return false
+		return abort.NewMalformedError(name, "w is not in [0, NTilde)")
 	}
 	if !prime.IsInInterval(pf.S, pk.N) {
-		return false
+		return abort.NewMalformedError(name, "s is not in [0, N)")
 	}
 	if new(big.Int).GCD(nil, nil, pf.Z, NTilde).Cmp(one) != 0 {
-		return false
+		return abort.NewMalformedError(name, "z is not coprime to NTilde")
 	}
 	if new(big.Int).GCD(nil, nil, pf.U, pk.NSquare()).Cmp(one) != 0 {
-		return false
+		return abort.NewMalformedError(name, "u is not coprime to N^2")
 	}
 	if new(big.Int).GCD(nil, nil, pf.W, NTilde).Cmp(one) != 0 {
-		return false
+		return abort.NewMalformedError(name, "w is not coprime to NTilde")
 	}
 
 	// 3.
 	if pf.S1.Cmp(q3) == 1 {
-		return false
+		return abort.NewRejectedError(name, "3", "s1 > q^3")
 	}
 
 	// 1-2. e'
-	var e *big.Int
-	{ // must use RejectionSample
-		eHash := cmt.SHA512_256i(append(pk.AsInts(), c, pf.Z, pf.U, pf.W)...)
-		e = cmt.RejectionSample(q, eHash)
-	}
+	e := rangeProofAliceChallenge(q, pk, NTilde, h1, h2, c, pf.Z, pf.U, pf.W, cmt.OptionalSession(optionalSession))
 
 	var products *big.Int // for the following conditionals
 	minusE := new(big.Int).Sub(zero, e)
@@ -161,7 +179,7 @@ func (pf *RangeProofAlice) Verify(ec elliptic.Curve, pk *paillier.PublicKey, NTi
 		products = modNSquared.Mul(gammaExpS1, sExpN)
 		products = modNSquared.Mul(products, cExpMinusE)
 		if pf.U.Cmp(products) != 0 {
-			return false
+			return abort.NewRejectedError(name, "4", "u != gamma^s1 * s^N * c^-e mod N^2")
 		}
 	}
 
@@ -175,10 +193,10 @@ func (pf *RangeProofAlice) Verify(ec elliptic.Curve, pk *paillier.PublicKey, NTi
 		products = modNTilde.Mul(h1ExpS1, h2ExpS2)
 		products = modNTilde.Mul(products, zExpMinusE)
 		if pf.W.Cmp(products) != 0 {
-			return false
+			return abort.NewRejectedError(name, "5", "w != h1^s1 * h2^s2 * z^-e mod NTilde")
 		}
 	}
-	return true
+	return nil
 }
 
 func (pf *RangeProofAlice) ValidateBasic() bool {
@@ -200,3 +218,16 @@ func (pf *RangeProofAlice) Bytes() [RangeProofAliceBytesParts][]byte {
 		pf.S2.Bytes(),
 	}
 }
+
+// ----- utils
+
+// rangeProofAliceChallenge derives e. The hash without a session never covered NTilde, h1 and h2.
+func rangeProofAliceChallenge(q *big.Int, pk *paillier.PublicKey, NTilde, h1, h2, c, z, u, w *big.Int, session *cmt.Session) *big.Int {
+	ch := &cmt.Challenge{
+		Domain:     "zk-proof/mta/range-proof-alice/v1",
+		Statement:  append(pk.AsInts(), NTilde, h1, h2, c),
+		Commitment: []*big.Int{z, u, w},
+		Legacy:     append(pk.AsInts(), c, z, u, w),
+	}
+	return ch.Scalar(session, q)
+}
@@ -0,0 +1,80 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package mta
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"github.com/zhp12543/zk-proof/abort"
+	"github.com/zhp12543/zk-proof/cmt"
+	"github.com/zhp12543/zk-proof/curve"
+	"math/big"
+	"testing"
+)
+
+func TestRangeProofAliceVerifyWithReason(t *testing.T) {
+	ec := elliptic.P256()
+	alice, bob := setUp(t)
+	a := curve.GetRandomPositiveInt(ec.Params().N)
+	cA, pf, err := AliceInit(ec, alice.pk, a, bob.NTilde, bob.h1, bob.h2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = pf.VerifyWithReason(ec, alice.pk, bob.NTilde, bob.h1, bob.h2, cA); err != nil {
+		t.Fatalf("valid proof was rejected: %v", err)
+	}
+
+	q3 := new(big.Int).Exp(ec.Params().N, big.NewInt(3), nil)
+	tests := []struct {
+		name   string
+		tamper func(pf *RangeProofAlice)
+		kind   abort.ReasonKind
+		step   string
+	}{
+		{"z not coprime", func(pf *RangeProofAlice) { pf.Z = big.NewInt(0) }, abort.Malformed, ""},
+		{"s1 too large", func(pf *RangeProofAlice) { pf.S1 = new(big.Int).Add(q3, big.NewInt(1)) }, abort.Rejected, "3"},
+		{"s changed", func(pf *RangeProofAlice) { pf.S = new(big.Int).Add(pf.S, big.NewInt(1)) }, abort.Rejected, "4"},
+		{"s2 changed", func(pf *RangeProofAlice) { pf.S2 = new(big.Int).Add(pf.S2, big.NewInt(1)) }, abort.Rejected, "5"},
+	}
+	for _, tt := range tests {
+		bad := *pf
+		tt.tamper(&bad)
+		err := bad.VerifyWithReason(ec, alice.pk, bob.NTilde, bob.h1, bob.h2, cA)
+		var ve *abort.VerifyError
+		if !errors.As(err, &ve) || ve.Kind != tt.kind || ve.Step != tt.step {
+			t.Errorf("%s: unexpected reason %v", tt.name, err)
+		}
+		if bad.Verify(ec, alice.pk, bob.NTilde, bob.h1, bob.h2, cA) {
+			t.Errorf("%s: Verify returned true", tt.name)
+		}
+	}
+}
+
+func TestRangeProofAliceSession(t *testing.T) {
+	ec := elliptic.P256()
+	alice, bob := setUp(t)
+	a := curve.GetRandomPositiveInt(ec.Params().N)
+	cA, rA, err := alice.pk.EncryptAndReturnRandomness(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, _ := cmt.NewSession([]byte("session"), big.NewInt(1))
+	other, _ := cmt.NewSession([]byte("session"), big.NewInt(2))
+	pf, err := ProveRangeAlice(ec, alice.pk, cA, bob.NTilde, bob.h1, bob.h2, a, rA, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pf.Verify(ec, alice.pk, bob.NTilde, bob.h1, bob.h2, cA, session) {
+		t.Fatal("proof did not verify in its session")
+	}
+	if pf.Verify(ec, alice.pk, bob.NTilde, bob.h1, bob.h2, cA, other) {
+		t.Error("proof verified for another party")
+	}
+	if pf.Verify(ec, alice.pk, bob.NTilde, bob.h1, bob.h2, cA) {
+		t.Error("proof verified without its session")
+	}
+}
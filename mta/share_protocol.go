@@ -8,10 +8,12 @@ package mta
 
 import (
 	"crypto/elliptic"
-	"errors"
+	"crypto/rand"
+	"github.com/zhp12543/zk-proof/cmt"
 	"github.com/zhp12543/zk-proof/curve"
 	"github.com/zhp12543/zk-proof/paillier"
 	"github.com/zhp12543/zk-proof/prime"
+	"io"
 	"math/big"
 )
 
@@ -19,78 +21,122 @@ func AliceInit(
 	ec elliptic.Curve,
 	pkA *paillier.PublicKey,
 	a, NTildeB, h1B, h2B *big.Int,
+	optionalSession ...*cmt.Session,
 ) (cA *big.Int, pf *RangeProofAlice, err error) {
-	cA, rA, err := pkA.EncryptAndReturnRandomness(a)
+	return AliceInitWithReader(rand.Reader, ec, pkA, a, NTildeB, h1B, h2B, optionalSession...)
+}
+
+// AliceInitWithReader is AliceInit drawing the encryption and proof randomness from `reader`
+func AliceInitWithReader(
+	reader io.Reader,
+	ec elliptic.Curve,
+	pkA *paillier.PublicKey,
+	a, NTildeB, h1B, h2B *big.Int,
+	optionalSession ...*cmt.Session,
+) (cA *big.Int, pf *RangeProofAlice, err error) {
+	cA, rA, err := pkA.EncryptAndReturnRandomnessWithReader(reader, a)
 	if err != nil {
 		return nil, nil, err
 	}
-	pf, err = ProveRangeAlice(ec, pkA, cA, NTildeB, h1B, h2B, a, rA)
+	pf, err = ProveRangeAliceWithReader(reader, ec, pkA, cA, NTildeB, h1B, h2B, a, rA, optionalSession...)
 	return cA, pf, err
 }
 
+// BobMid checks Alice's message under her session and answers it with a ProofBob made under Bob's.
+// Either session may be nil, see cmt.Session.
 func BobMid(
 	ec elliptic.Curve,
 	pkA *paillier.PublicKey,
 	pf *RangeProofAlice,
 	b, cA, NTildeA, h1A, h2A, NTildeB, h1B, h2B *big.Int,
+	aliceSession, bobSession *cmt.Session,
 ) (beta, cB, betaPrm *big.Int, piB *ProofBob, err error) {
-	if !pf.Verify(ec, pkA, NTildeB, h1B, h2B, cA) {
-		err = errors.New("RangeProofAlice.Verify() returned false")
-		return
-	}
-	q := ec.Params().N
-	q5 := new(big.Int).Mul(q, q)  // q^2
-	q5 = new(big.Int).Mul(q5, q5) // q^4
-	q5 = new(big.Int).Mul(q5, q)  // q^5
-	betaPrm = curve.GetRandomPositiveInt(q5)
-	cBetaPrm, cRand, err := pkA.EncryptAndReturnRandomness(betaPrm)
-	if err != nil {
-		return
-	}
-	cB, err = pkA.HomoMult(b, cA)
-	if err != nil {
-		return
-	}
-	cB, err = pkA.HomoAdd(cB, cBetaPrm)
-	if err != nil {
+	return BobMidWithReader(rand.Reader, ec, pkA, pf, b, cA, NTildeA, h1A, h2A, NTildeB, h1B, h2B, aliceSession, bobSession)
+}
+
+// BobMidWithReader is BobMid drawing beta', the encryption and the proof randomness from `reader`
+func BobMidWithReader(
+	reader io.Reader,
+	ec elliptic.Curve,
+	pkA *paillier.PublicKey,
+	pf *RangeProofAlice,
+	b, cA, NTildeA, h1A, h2A, NTildeB, h1B, h2B *big.Int,
+	aliceSession, bobSession *cmt.Session,
+) (beta, cB, betaPrm *big.Int, piB *ProofBob, err error) {
+	if err = VerifyAliceInit(ec, pkA, pf, cA, NTildeB, h1B, h2B, aliceSession); err != nil {
 		return
 	}
-	beta = prime.ModInt(q).Sub(zero, betaPrm)
-	piB, err = ProveBob(ec, pkA, NTildeA, h1A, h2A, cA, cB, b, betaPrm, cRand)
-	return
+	return bobMidPreVerified(reader, ec, pkA, b, cA, NTildeA, h1A, h2A, bobSession)
+}
+
+// BobMidPreVerified is BobMid for a message from Alice that has already passed VerifyAliceInit,
+// so that BobMid and BobMidWC can answer the same message without checking the range proof twice.
+// The optional session is Bob's.
+func BobMidPreVerified(
+	ec elliptic.Curve,
+	pkA *paillier.PublicKey,
+	b, cA, NTildeA, h1A, h2A *big.Int,
+	optionalSession ...*cmt.Session,
+) (beta, cB, betaPrm *big.Int, piB *ProofBob, err error) {
+	return bobMidPreVerified(rand.Reader, ec, pkA, b, cA, NTildeA, h1A, h2A, optionalSession...)
 }
 
+// BobMidWC is BobMid answering with a ProofBobWC, which also proves that B = g^b
 func BobMidWC(
 	ec elliptic.Curve,
 	pkA *paillier.PublicKey,
 	pf *RangeProofAlice,
 	b, cA, NTildeA, h1A, h2A, NTildeB, h1B, h2B *big.Int,
 	B *curve.ECPoint,
+	aliceSession, bobSession *cmt.Session,
 ) (beta, cB, betaPrm *big.Int, piB *ProofBobWC, err error) {
-	if !pf.Verify(ec, pkA, NTildeB, h1B, h2B, cA) {
-		err = errors.New("RangeProofAlice.Verify() returned false")
-		return
-	}
-	q := ec.Params().N
-	q5 := new(big.Int).Mul(q, q)  // q^2
-	q5 = new(big.Int).Mul(q5, q5) // q^4
-	q5 = new(big.Int).Mul(q5, q)  // q^5
-	betaPrm = curve.GetRandomPositiveInt(q5)
-	cBetaPrm, cRand, err := pkA.EncryptAndReturnRandomness(betaPrm)
-	if err != nil {
+	return BobMidWCWithReader(rand.Reader, ec, pkA, pf, b, cA, NTildeA, h1A, h2A, NTildeB, h1B, h2B, B, aliceSession, bobSession)
+}
+
+// BobMidWCWithReader is BobMidWC drawing beta', the encryption and the proof randomness from `reader`
+func BobMidWCWithReader(
+	reader io.Reader,
+	ec elliptic.Curve,
+	pkA *paillier.PublicKey,
+	pf *RangeProofAlice,
+	b, cA, NTildeA, h1A, h2A, NTildeB, h1B, h2B *big.Int,
+	B *curve.ECPoint,
+	aliceSession, bobSession *cmt.Session,
+) (beta, cB, betaPrm *big.Int, piB *ProofBobWC, err error) {
+	if err = VerifyAliceInit(ec, pkA, pf, cA, NTildeB, h1B, h2B, aliceSession); err != nil {
 		return
 	}
-	cB, err = pkA.HomoMult(b, cA)
-	if err != nil {
-		return
+	return bobMidWCPreVerified(reader, ec, pkA, b, cA, NTildeA, h1A, h2A, B, bobSession)
+}
+
+// BobMidWCPreVerified is BobMidWC for a message from Alice that has already passed VerifyAliceInit.
+// The optional session is Bob's.
+func BobMidWCPreVerified(
+	ec elliptic.Curve,
+	pkA *paillier.PublicKey,
+	b, cA, NTildeA, h1A, h2A *big.Int,
+	B *curve.ECPoint,
+	optionalSession ...*cmt.Session,
+) (beta, cB, betaPrm *big.Int, piB *ProofBobWC, err error) {
+	return bobMidWCPreVerified(rand.Reader, ec, pkA, b, cA, NTildeA, h1A, h2A, B, optionalSession...)
+}
+
+// VerifyAliceInit checks Alice's range proof and ciphertext, as BobMid and BobMidWC do before answering.
+// A failure is returned as a *ProofError.
+func VerifyAliceInit(
+	ec elliptic.Curve,
+	pkA *paillier.PublicKey,
+	pf *RangeProofAlice,
+	cA, NTildeB, h1B, h2B *big.Int,
+	optionalSession ...*cmt.Session,
+) error {
+	if vErr := pf.VerifyWithReason(ec, pkA, NTildeB, h1B, h2B, cA, optionalSession...); vErr != nil {
+		return &ProofError{Proof: "RangeProofAlice", Step: StepVerify, Err: vErr}
 	}
-	cB, err = pkA.HomoAdd(cB, cBetaPrm)
-	if err != nil {
-		return
+	if cErr := pkA.ValidateCiphertext(cA); cErr != nil {
+		return &ProofError{Proof: "RangeProofAlice", Step: StepCiphertext, Err: cErr}
 	}
-	beta = prime.ModInt(q).Sub(zero, betaPrm)
-	piB, err = ProveBobWC(ec, pkA, NTildeA, h1A, h2A, cA, cB, b, betaPrm, cRand, B)
-	return
+	return nil
 }
 
 func AliceEnd(
@@ -99,13 +145,14 @@ func AliceEnd(
 	pf *ProofBob,
 	h1A, h2A, cA, cB, NTildeA *big.Int,
 	sk *paillier.PrivateKey,
+	optionalSession ...*cmt.Session,
 ) (*big.Int, error) {
-	if !pf.Verify(ec, pkA, NTildeA, h1A, h2A, cA, cB) {
-		return nil, errors.New("ProofBob.Verify() returned false")
+	if err := pf.VerifyWithReason(ec, pkA, NTildeA, h1A, h2A, cA, cB, optionalSession...); err != nil {
+		return nil, &ProofError{Proof: "ProofBob", Step: StepVerify, Err: err}
 	}
-	alphaPrm, err := sk.Decrypt(cB)
+	alphaPrm, err := sk.DecryptCRT(cB)
 	if err != nil {
-		return nil, err
+		return nil, &ProofError{Proof: "ProofBob", Step: StepDecryptReply, Err: err}
 	}
 	q := ec.Params().N
 	return new(big.Int).Mod(alphaPrm, q), nil
@@ -118,14 +165,78 @@ func AliceEndWC(
 	B *curve.ECPoint,
 	cA, cB, NTildeA, h1A, h2A *big.Int,
 	sk *paillier.PrivateKey,
+	optionalSession ...*cmt.Session,
 ) (*big.Int, error) {
-	if !pf.Verify(ec, pkA, NTildeA, h1A, h2A, cA, cB, B) {
-		return nil, errors.New("ProofBobWC.Verify() returned false")
+	if err := pf.VerifyWithReason(ec, pkA, NTildeA, h1A, h2A, cA, cB, B, optionalSession...); err != nil {
+		return nil, &ProofError{Proof: "ProofBobWC", Step: StepVerify, Err: err}
 	}
-	alphaPrm, err := sk.Decrypt(cB)
+	alphaPrm, err := sk.DecryptCRT(cB)
 	if err != nil {
-		return nil, err
+		return nil, &ProofError{Proof: "ProofBobWC", Step: StepDecryptReply, Err: err}
 	}
 	q := ec.Params().N
 	return new(big.Int).Mod(alphaPrm, q), nil
 }
+
+// ----- utils
+
+func bobMidPreVerified(
+	reader io.Reader,
+	ec elliptic.Curve,
+	pkA *paillier.PublicKey,
+	b, cA, NTildeA, h1A, h2A *big.Int,
+	optionalSession ...*cmt.Session,
+) (beta, cB, betaPrm *big.Int, piB *ProofBob, err error) {
+	beta, cB, betaPrm, cRand, err := bobEncrypt(reader, ec, pkA, b, cA)
+	if err != nil {
+		return
+	}
+	piB, err = ProveBobWithReader(reader, ec, pkA, NTildeA, h1A, h2A, cA, cB, b, betaPrm, cRand, optionalSession...)
+	return
+}
+
+func bobMidWCPreVerified(
+	reader io.Reader,
+	ec elliptic.Curve,
+	pkA *paillier.PublicKey,
+	b, cA, NTildeA, h1A, h2A *big.Int,
+	B *curve.ECPoint,
+	optionalSession ...*cmt.Session,
+) (beta, cB, betaPrm *big.Int, piB *ProofBobWC, err error) {
+	beta, cB, betaPrm, cRand, err := bobEncrypt(reader, ec, pkA, b, cA)
+	if err != nil {
+		return
+	}
+	piB, err = ProveBobWCWithReader(reader, ec, pkA, NTildeA, h1A, h2A, cA, cB, b, betaPrm, cRand, B, optionalSession...)
+	return
+}
+
+// bobEncrypt computes cB = b * cA + Enc(beta') for a random beta' < q^5 and returns beta = -beta' mod q
+func bobEncrypt(
+	reader io.Reader,
+	ec elliptic.Curve,
+	pkA *paillier.PublicKey,
+	b, cA *big.Int,
+) (beta, cB, betaPrm, cRand *big.Int, err error) {
+	q := ec.Params().N
+	q5 := new(big.Int).Mul(q, q)  // q^2
+	q5 = new(big.Int).Mul(q5, q5) // q^4
+	q5 = new(big.Int).Mul(q5, q)  // q^5
+	if betaPrm, err = curve.GetRandomPositiveIntWithReader(reader, q5); err != nil {
+		return
+	}
+	cBetaPrm, cRand, err := pkA.EncryptAndReturnRandomnessWithReader(reader, betaPrm)
+	if err != nil {
+		return
+	}
+	cB, err = pkA.HomoMult(b, cA)
+	if err != nil {
+		return
+	}
+	cB, err = pkA.HomoAdd(cB, cBetaPrm)
+	if err != nil {
+		return
+	}
+	beta = prime.ModInt(q).Sub(zero, betaPrm)
+	return
+}
@@ -0,0 +1,90 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package mta_test
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"github.com/zhp12543/zk-proof/cmt"
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/mta"
+	"github.com/zhp12543/zk-proof/test"
+	"math/big"
+	"testing"
+)
+
+// TestShareProtocolSession runs AliceInit, BobMid(WC) and AliceEnd(WC) with each side's proofs bound to its session.
+// It uses the production-sized fixtures, as the q^5 mask of BobMid does not fit in the small mta test keys.
+func TestShareProtocolSession(t *testing.T) {
+	ec := elliptic.P256()
+	q := ec.Params().N
+	preParams, err := test.LoadPreParamsN(0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice, bob := preParams[0], preParams[1]
+	pkA, skA := &alice.PaillierSK.PublicKey, alice.PaillierSK
+	aliceSession := &cmt.Session{ID: []byte("session"), Party: big.NewInt(1)}
+	bobSession := &cmt.Session{ID: []byte("session"), Party: big.NewInt(2)}
+
+	a, b := curve.GetRandomPositiveInt(q), curve.GetRandomPositiveInt(q)
+	B := curve.ScalarBaseMult(ec, b)
+	cA, pf, err := mta.AliceInit(ec, pkA, a, bob.NTildei, bob.H1i, bob.H2i, aliceSession)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectProofError := func(what string, err error, proof string) {
+		t.Helper()
+		var pErr *mta.ProofError
+		if !errors.As(err, &pErr) || pErr.Proof != proof || pErr.Step != mta.StepVerify {
+			t.Errorf("%s: expected %s to fail verification but got %v", what, proof, err)
+		}
+	}
+	ab := new(big.Int).Mul(a, b)
+	ab = ab.Mod(ab, q)
+	checkShares := func(what string, alpha, beta *big.Int) {
+		t.Helper()
+		sum := new(big.Int).Add(alpha, beta)
+		if sum.Mod(sum, q).Cmp(ab) != 0 {
+			t.Errorf("%s: alpha + beta != a * b", what)
+		}
+	}
+
+	beta, cB, _, piB, err := mta.BobMid(ec, pkA, pf, b, cA, alice.NTildei, alice.H1i, alice.H2i,
+		bob.NTildei, bob.H1i, bob.H2i, aliceSession, bobSession)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alpha, err := mta.AliceEnd(ec, pkA, piB, alice.H1i, alice.H2i, cA, cB, alice.NTildei, skA, bobSession)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkShares("BobMid", alpha, beta)
+	_, err = mta.AliceEnd(ec, pkA, piB, alice.H1i, alice.H2i, cA, cB, alice.NTildei, skA, aliceSession)
+	expectProofError("AliceEnd in Alice's session", err, "ProofBob")
+
+	betaWC, cBWC, _, piBWC, err := mta.BobMidWC(ec, pkA, pf, b, cA, alice.NTildei, alice.H1i, alice.H2i,
+		bob.NTildei, bob.H1i, bob.H2i, B, aliceSession, bobSession)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alphaWC, err := mta.AliceEndWC(ec, pkA, piBWC, B, cA, cBWC, alice.NTildei, alice.H1i, alice.H2i, skA, bobSession)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkShares("BobMidWC", alphaWC, betaWC)
+	_, err = mta.AliceEndWC(ec, pkA, piBWC, B, cA, cBWC, alice.NTildei, alice.H1i, alice.H2i, skA, nil)
+	expectProofError("AliceEndWC without a session", err, "ProofBobWC")
+
+	// Alice's range proof only verifies in her session
+	_, _, _, _, err = mta.BobMid(ec, pkA, pf, b, cA, alice.NTildei, alice.H1i, alice.H2i,
+		bob.NTildei, bob.H1i, bob.H2i, bobSession, bobSession)
+	expectProofError("BobMid in Bob's session", err, "RangeProofAlice")
+	_, _, _, _, err = mta.BobMidWC(ec, pkA, pf, b, cA, alice.NTildei, alice.H1i, alice.H2i,
+		bob.NTildei, bob.H1i, bob.H2i, B, nil, bobSession)
+	expectProofError("BobMidWC without a session", err, "RangeProofAlice")
+}
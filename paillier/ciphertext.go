@@ -0,0 +1,160 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package paillier
+
+import (
+	"fmt"
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/prime"
+	"math/big"
+)
+
+type (
+	// Ciphertext is an element of Z*_N2 bound to the PublicKey it was produced under.
+	// The homomorphic operations refuse to mix ciphertexts from different keys.
+	Ciphertext struct {
+		publicKey *PublicKey
+		c         *big.Int
+	}
+)
+
+var (
+	ErrCiphertextMalFormed   = fmt.Errorf("the ciphertext is not in Z*_N2")
+	ErrCiphertextKeyMismatch = fmt.Errorf("the ciphertexts were produced under different keys")
+)
+
+// NewCiphertext wraps c after checking that it is in Z*_N2
+func (publicKey *PublicKey) NewCiphertext(c *big.Int) (*Ciphertext, error) {
+	if err := publicKey.ValidateCiphertext(c); err != nil {
+		return nil, err
+	}
+	return &Ciphertext{publicKey: publicKey, c: new(big.Int).Set(c)}, nil
+}
+
+// ValidateCiphertext returns ErrCiphertextMalFormed unless c is in Z*_N2.
+// It lets callers that keep *big.Int ciphertexts apply the same check as NewCiphertext.
+func (publicKey *PublicKey) ValidateCiphertext(c *big.Int) error {
+	if publicKey == nil || publicKey.N == nil || !curve.IsNumberInMultiplicativeGroup(publicKey.NSquare(), c) {
+		return ErrCiphertextMalFormed
+	}
+	return nil
+}
+
+func (publicKey *PublicKey) EncryptToCiphertext(m *big.Int) (ct *Ciphertext, x *big.Int, err error) {
+	c, x, err := publicKey.EncryptAndReturnRandomness(m)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &Ciphertext{publicKey: publicKey, c: c}, x, nil
+}
+
+// Rerandomize returns c * x^N mod N2 for a fresh x, which decrypts to the same plaintext as c
+func (publicKey *PublicKey) Rerandomize(c *big.Int) (cPrm *big.Int, x *big.Int, err error) {
+	x = curve.GetRandomPositiveRelativelyPrimeInt(publicKey.N)
+	cPrm, err = publicKey.RerandomizeWithRandomness(c, x)
+	return
+}
+
+// RerandomizeWithRandomness is Rerandomize with a caller-supplied x in Z*_N
+func (publicKey *PublicKey) RerandomizeWithRandomness(c, x *big.Int) (*big.Int, error) {
+	if err := publicKey.ValidateCiphertext(c); err != nil {
+		return nil, err
+	}
+	if !curve.IsNumberInMultiplicativeGroup(publicKey.N, x) {
+		return nil, ErrRandomnessMalFormed
+	}
+	modN2 := prime.ModInt(publicKey.NSquare())
+	return modN2.Mul(c, modN2.Exp(x, publicKey.N)), nil
+}
+
+// Equals reports whether both keys have the same modulus
+func (publicKey *PublicKey) Equals(other *PublicKey) bool {
+	if publicKey == nil || other == nil || publicKey.N == nil || other.N == nil {
+		return false
+	}
+	return publicKey.N.Cmp(other.N) == 0
+}
+
+// ----- //
+
+// Int returns a copy of the ciphertext value for use with the *big.Int based APIs
+func (ct *Ciphertext) Int() *big.Int {
+	return new(big.Int).Set(ct.c)
+}
+
+func (ct *Ciphertext) PublicKey() *PublicKey {
+	return ct.publicKey
+}
+
+func (ct *Ciphertext) Equals(other *Ciphertext) bool {
+	if ct == nil || other == nil {
+		return false
+	}
+	return ct.publicKey.Equals(other.publicKey) && ct.c.Cmp(other.c) == 0
+}
+
+func (ct *Ciphertext) Add(other *Ciphertext) (*Ciphertext, error) {
+	if err := ct.checkSameKey(other); err != nil {
+		return nil, err
+	}
+	c, err := ct.publicKey.HomoAdd(ct.c, other.c)
+	if err != nil {
+		return nil, err
+	}
+	return &Ciphertext{publicKey: ct.publicKey, c: c}, nil
+}
+
+func (ct *Ciphertext) Sub(other *Ciphertext) (*Ciphertext, error) {
+	if err := ct.checkSameKey(other); err != nil {
+		return nil, err
+	}
+	c, err := ct.publicKey.HomoSub(ct.c, other.c)
+	if err != nil {
+		return nil, err
+	}
+	return &Ciphertext{publicKey: ct.publicKey, c: c}, nil
+}
+
+func (ct *Ciphertext) Mult(m *big.Int) (*Ciphertext, error) {
+	c, err := ct.publicKey.HomoMult(m, ct.c)
+	if err != nil {
+		return nil, err
+	}
+	return &Ciphertext{publicKey: ct.publicKey, c: c}, nil
+}
+
+func (ct *Ciphertext) Rerandomize() (*Ciphertext, *big.Int, error) {
+	c, x, err := ct.publicKey.Rerandomize(ct.c)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &Ciphertext{publicKey: ct.publicKey, c: c}, x, nil
+}
+
+// ----- //
+
+func (privateKey *PrivateKey) DecryptCiphertext(ct *Ciphertext) (m *big.Int, err error) {
+	if ct == nil {
+		return nil, ErrCiphertextMalFormed
+	}
+	if !privateKey.PublicKey.Equals(ct.publicKey) {
+		return nil, ErrCiphertextKeyMismatch
+	}
+	return privateKey.DecryptCRT(ct.c)
+}
+
+// ----- utils
+
+func (ct *Ciphertext) checkSameKey(other *Ciphertext) error {
+	if other == nil {
+		return ErrCiphertextMalFormed
+	}
+	if !ct.publicKey.Equals(other.publicKey) {
+		return ErrCiphertextKeyMismatch
+	}
+	return nil
+}
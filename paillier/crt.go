@@ -0,0 +1,101 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package paillier
+
+import (
+	"github.com/zhp12543/zk-proof/prime"
+	"math/big"
+)
+
+type (
+	// PrecomputedValues holds private key material derived once from P and Q so that
+	// decryption can be carried out with the Chinese Remainder Theorem.
+	// mu is left out: only Decrypt uses it, and it is derived on its own so that DecryptCRT never pays for it.
+	PrecomputedValues struct {
+		PSquare, QSquare, // p^2, q^2
+		PInvQ, // p^-1 mod q
+		Hp, Hq *big.Int // L_p(Gamma^(p-1) mod p^2)^-1 mod p, L_q(Gamma^(q-1) mod q^2)^-1 mod q
+	}
+)
+
+// Precompute derives the values used by Decrypt and DecryptCRT up front instead of on first use.
+func (privateKey *PrivateKey) Precompute() *PrecomputedValues {
+	privateKey.getMu()
+	return privateKey.PrecomputedValues()
+}
+
+// PrecomputedValues returns the values used by DecryptCRT, deriving and caching them on the first call.
+// Keys decoded from JSON start without them. It returns nil, without caching it, when the key does not carry P and Q.
+func (privateKey *PrivateKey) PrecomputedValues() *PrecomputedValues {
+	privateKey.crtMtx.Lock()
+	defer privateKey.crtMtx.Unlock()
+	P, Q := privateKey.P, privateKey.Q
+	if privateKey.crt != nil || P == nil || Q == nil {
+		return privateKey.crt
+	}
+	pv := &PrecomputedValues{
+		PSquare: new(big.Int).Mul(P, P),
+		QSquare: new(big.Int).Mul(Q, Q),
+		PInvQ:   new(big.Int).ModInverse(P, Q),
+	}
+	pv.Hp = crtH(privateKey.Gamma(), P, pv.PSquare)
+	pv.Hq = crtH(privateKey.Gamma(), Q, pv.QSquare)
+	privateKey.crt = pv
+	return pv
+}
+
+// getMu returns L(Gamma^LambdaN mod N2)^-1 mod N used by Decrypt, deriving and caching it on the first call
+func (privateKey *PrivateKey) getMu() *big.Int {
+	privateKey.muOnce.Do(func() {
+		N2 := privateKey.NSquare()
+		Lg := L(new(big.Int).Exp(privateKey.Gamma(), privateKey.LambdaN, N2), privateKey.N)
+		privateKey.mu = new(big.Int).ModInverse(Lg, privateKey.N)
+	})
+	return privateKey.mu
+}
+
+// DecryptCRT is equivalent to Decrypt but works modulo p^2 and q^2 and recombines the result with the CRT.
+// It falls back to Decrypt when the key does not carry P and Q.
+func (privateKey *PrivateKey) DecryptCRT(c *big.Int) (m *big.Int, err error) {
+	pv := privateKey.PrecomputedValues()
+	if pv == nil {
+		return privateKey.Decrypt(c)
+	}
+	N2 := privateKey.NSquare()
+	if c.Cmp(zero) == -1 || c.Cmp(N2) != -1 { // c < 0 || c >= N2 ?
+		return nil, ErrMessageTooLong
+	}
+	cg := new(big.Int).GCD(nil, nil, c, N2)
+	if cg.Cmp(one) == 1 {
+		return nil, ErrMessageMalFormed
+	}
+	P, Q := privateKey.P, privateKey.Q
+	// 1. mp = L_p(c^(p-1) mod p^2) * hp mod p
+	mp := crtDecryptPart(c, P, pv.PSquare, pv.Hp)
+	// 2. mq = L_q(c^(q-1) mod q^2) * hq mod q
+	mq := crtDecryptPart(c, Q, pv.QSquare, pv.Hq)
+	// 3. m = mp + p * ((mq - mp) * p^-1 mod q)
+	h := prime.ModInt(Q).Mul(new(big.Int).Sub(mq, mp), pv.PInvQ)
+	m = new(big.Int).Mul(h, P)
+	m = m.Add(m, mp)
+	return
+}
+
+// ----- utils
+
+func crtH(gamma, p, pSquare *big.Int) *big.Int {
+	pMinus1 := new(big.Int).Sub(p, one)
+	Lp := L(new(big.Int).Exp(gamma, pMinus1, pSquare), p)
+	return new(big.Int).ModInverse(Lp, p)
+}
+
+func crtDecryptPart(c, p, pSquare, hp *big.Int) *big.Int {
+	pMinus1 := new(big.Int).Sub(p, one)
+	cp := new(big.Int).Mod(c, pSquare)
+	Lc := L(cp.Exp(cp, pMinus1, pSquare), p)
+	return prime.ModInt(p).Mul(Lc, hp)
+}
@@ -0,0 +1,191 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+// The Damgård–Jurik crypto-system generalises Paillier to a plaintext space of Z_N^s with ciphertexts in Z*_N^(s+1).
+// With s = 1 it is exactly the Paillier crypto-system above.
+//
+// Implementation adheres to Damgård, I., Jurik, M.: A Generalisation, a Simplification and Some Applications of
+// Paillier's Probabilistic Public-Key System. In: PKC 2001, Theorem 1 and Section 4.1
+
+package paillier
+
+import (
+	"context"
+	"errors"
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/prime"
+	"math/big"
+)
+
+type (
+	DJPublicKey struct {
+		N *big.Int
+		S int
+	}
+
+	DJPrivateKey struct {
+		DJPublicKey
+		LambdaN *big.Int // lcm(p-1, q-1)
+		P, Q    *big.Int
+	}
+)
+
+// GenerateDJKeyPair generates a Paillier key pair with GenerateKeyPair and extends it to the plaintext space Z_N^s
+func GenerateDJKeyPair(ctx context.Context, modulusBitLen, s int, optionalConcurrency ...int) (privateKey *DJPrivateKey, publicKey *DJPublicKey, err error) {
+	if s < 1 {
+		return nil, nil, errors.New("GenerateDJKeyPair: s must be >= 1")
+	}
+	sk, _, err := GenerateKeyPair(ctx, modulusBitLen, optionalConcurrency...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if privateKey, err = sk.DamgardJurik(s); err != nil {
+		return nil, nil, err
+	}
+	return privateKey, &privateKey.DJPublicKey, nil
+}
+
+// DamgardJurik returns the Damgård–Jurik key with the same modulus and plaintext space Z_N^s
+func (privateKey *PrivateKey) DamgardJurik(s int) (*DJPrivateKey, error) {
+	if s < 1 {
+		return nil, errors.New("DamgardJurik: s must be >= 1")
+	}
+	return &DJPrivateKey{
+		DJPublicKey: DJPublicKey{N: privateKey.N, S: s},
+		LambdaN:     privateKey.LambdaN,
+		P:           privateKey.P,
+		Q:           privateKey.Q,
+	}, nil
+}
+
+// DamgardJurik returns the Damgård–Jurik public key with the same modulus and plaintext space Z_N^s
+func (publicKey *PublicKey) DamgardJurik(s int) (*DJPublicKey, error) {
+	if s < 1 {
+		return nil, errors.New("DamgardJurik: s must be >= 1")
+	}
+	return &DJPublicKey{N: publicKey.N, S: s}, nil
+}
+
+// ----- //
+
+func (publicKey *DJPublicKey) EncryptAndReturnRandomness(m *big.Int) (c *big.Int, x *big.Int, err error) {
+	NS := publicKey.NS()
+	if m.Cmp(zero) == -1 || m.Cmp(NS) != -1 { // m < 0 || m >= N^s ?
+		return nil, nil, ErrMessageTooLong
+	}
+	x = curve.GetRandomPositiveRelativelyPrimeInt(publicKey.N)
+	NS1 := publicKey.NSPlusOne()
+	// 1. gamma^m mod N^(s+1)
+	Gm := new(big.Int).Exp(publicKey.Gamma(), m, NS1)
+	// 2. x^(N^s) mod N^(s+1)
+	xNS := new(big.Int).Exp(x, NS, NS1)
+	// 3. (1) * (2) mod N^(s+1)
+	c = prime.ModInt(NS1).Mul(Gm, xNS)
+	return
+}
+
+func (publicKey *DJPublicKey) Encrypt(m *big.Int) (c *big.Int, err error) {
+	c, _, err = publicKey.EncryptAndReturnRandomness(m)
+	return
+}
+
+func (publicKey *DJPublicKey) HomoMult(m, c1 *big.Int) (*big.Int, error) {
+	if m.Cmp(zero) == -1 || m.Cmp(publicKey.NS()) != -1 { // m < 0 || m >= N^s ?
+		return nil, ErrMessageTooLong
+	}
+	NS1 := publicKey.NSPlusOne()
+	if c1.Cmp(zero) == -1 || c1.Cmp(NS1) != -1 { // c1 < 0 || c1 >= N^(s+1) ?
+		return nil, ErrMessageTooLong
+	}
+	// cipher^m mod N^(s+1)
+	return prime.ModInt(NS1).Exp(c1, m), nil
+}
+
+func (publicKey *DJPublicKey) HomoAdd(c1, c2 *big.Int) (*big.Int, error) {
+	NS1 := publicKey.NSPlusOne()
+	if c1.Cmp(zero) == -1 || c1.Cmp(NS1) != -1 { // c1 < 0 || c1 >= N^(s+1) ?
+		return nil, ErrMessageTooLong
+	}
+	if c2.Cmp(zero) == -1 || c2.Cmp(NS1) != -1 { // c2 < 0 || c2 >= N^(s+1) ?
+		return nil, ErrMessageTooLong
+	}
+	// c1 * c2 mod N^(s+1)
+	return prime.ModInt(NS1).Mul(c1, c2), nil
+}
+
+// NS returns N^s, the size of the plaintext space
+func (publicKey *DJPublicKey) NS() *big.Int {
+	return new(big.Int).Exp(publicKey.N, big.NewInt(int64(publicKey.S)), nil)
+}
+
+// NSPlusOne returns N^(s+1), the ciphertext modulus
+func (publicKey *DJPublicKey) NSPlusOne() *big.Int {
+	return new(big.Int).Exp(publicKey.N, big.NewInt(int64(publicKey.S+1)), nil)
+}
+
+// AsInts returns the DJPublicKey serialised to a slice of *big.Int for hashing
+func (publicKey *DJPublicKey) AsInts() []*big.Int {
+	return []*big.Int{publicKey.N, big.NewInt(int64(publicKey.S)), publicKey.Gamma()}
+}
+
+// Gamma returns N+1
+func (publicKey *DJPublicKey) Gamma() *big.Int {
+	return new(big.Int).Add(publicKey.N, one)
+}
+
+// ----- //
+
+func (privateKey *DJPrivateKey) Decrypt(c *big.Int) (m *big.Int, err error) {
+	NS1 := privateKey.NSPlusOne()
+	if c.Cmp(zero) == -1 || c.Cmp(NS1) != -1 { // c < 0 || c >= N^(s+1) ?
+		return nil, ErrMessageTooLong
+	}
+	cg := new(big.Int).GCD(nil, nil, c, privateKey.N)
+	if cg.Cmp(one) == 1 {
+		return nil, ErrMessageMalFormed
+	}
+	NS := privateKey.NS()
+	// 1. c^LambdaN = (1+N)^(m*LambdaN mod N^s) mod N^(s+1)
+	a := new(big.Int).Exp(c, privateKey.LambdaN, NS1)
+	// 2. recover m*LambdaN mod N^s
+	mLambda := djLog(a, privateKey.N, privateKey.S)
+	// 3. (2) * modInv(LambdaN) mod N^s
+	inv := new(big.Int).ModInverse(privateKey.LambdaN, NS)
+	if inv == nil {
+		return nil, ErrMessageMalFormed
+	}
+	m = prime.ModInt(NS).Mul(mLambda, inv)
+	return
+}
+
+// ----- utils
+
+// djLog computes i from a = (1+N)^i mod N^(s+1), following the recursive algorithm in Section 3 of the paper
+func djLog(a, N *big.Int, s int) *big.Int {
+	i := big.NewInt(0)
+	NJ := new(big.Int).Set(N) // N^j
+	for j := 1; j <= s; j++ {
+		NJ1 := new(big.Int).Mul(NJ, N) // N^(j+1)
+		modNJ := prime.ModInt(NJ)
+		t1 := L(new(big.Int).Mod(a, NJ1), N)
+		t1 = t1.Mod(t1, NJ)
+		t2 := new(big.Int).Set(i)
+		kFact := big.NewInt(1)
+		NK := big.NewInt(1) // N^(k-1)
+		for k := 2; k <= j; k++ {
+			i = new(big.Int).Sub(i, one)
+			t2 = modNJ.Mul(t2, i)
+			kFact = kFact.Mul(kFact, big.NewInt(int64(k)))
+			NK = NK.Mul(NK, N)
+			term := modNJ.Mul(t2, NK)
+			term = modNJ.Mul(term, modNJ.ModInverse(kFact))
+			t1 = modNJ.Sub(t1, term)
+		}
+		i = t1
+		NJ = NJ1
+	}
+	return i
+}
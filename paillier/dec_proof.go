@@ -0,0 +1,189 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package paillier
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"github.com/zhp12543/zk-proof/abort"
+	"github.com/zhp12543/zk-proof/cmt"
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/prime"
+	"math/big"
+)
+
+const (
+	DecProofBytesParts = 7
+)
+
+type (
+	// DecProof is the proof of correct decryption Πdec from CGGMP21 Fig. 30.
+	// It shows that C decrypts to some y with y = x mod q for a published x.
+	DecProof struct {
+		S, T, A, Gamma, Z1, Z2, W *big.Int
+	}
+)
+
+// ProveDecryption decrypts C and proves that the plaintext equals the returned x modulo the curve order.
+// NCap, s, t are the verifier's ring-Pedersen parameters.
+func (privateKey *PrivateKey) ProveDecryption(ec elliptic.Curve, NCap, s, t, C *big.Int, optionalSession ...*cmt.Session) (x *big.Int, pf *DecProof, err error) {
+	if ec == nil || NCap == nil || s == nil || t == nil || C == nil {
+		return nil, nil, errors.New("ProveDecryption() received a nil argument")
+	}
+	y, err := privateKey.DecryptCRT(C)
+	if err != nil {
+		return nil, nil, err
+	}
+	rho, err := privateKey.recoverRandomness(C, y)
+	if err != nil {
+		return nil, nil, err
+	}
+	q := ec.Params().N
+	N0 := privateKey.N
+	// y < N0, so ℓ = |N0| and the slack ε = 2|q| covers the challenge
+	twoL := new(big.Int).Lsh(one, uint(N0.BitLen()))
+	twoLEps := new(big.Int).Lsh(one, uint(N0.BitLen()+2*q.BitLen()))
+
+	// 1. sample
+	alpha := curve.GetRandomPositiveInt(twoLEps)
+	mu := curve.GetRandomPositiveInt(new(big.Int).Mul(twoL, NCap))
+	nu := curve.GetRandomPositiveInt(new(big.Int).Mul(twoLEps, NCap))
+	r := curve.GetRandomPositiveRelativelyPrimeInt(N0)
+
+	// 2. compute
+	modNCap, modN0Squared, modN0 := prime.ModInt(NCap), prime.ModInt(privateKey.NSquare()), prime.ModInt(N0)
+	S := modNCap.Mul(modNCap.Exp(s, y), modNCap.Exp(t, mu))
+	T := modNCap.Mul(modNCap.Exp(s, alpha), modNCap.Exp(t, nu))
+	A := modN0Squared.Mul(modN0Squared.Exp(privateKey.Gamma(), alpha), modN0Squared.Exp(r, N0))
+	gamma := new(big.Int).Mod(alpha, q)
+	x = new(big.Int).Mod(y, q)
+
+	// 3. e
+	e := decChallenge(q, &privateKey.PublicKey, NCap, s, t, C, x, S, T, A, gamma, cmt.OptionalSession(optionalSession))
+
+	// 4.
+	z1 := new(big.Int).Mul(e, y)
+	z1 = z1.Add(z1, alpha)
+	z2 := new(big.Int).Mul(e, mu)
+	z2 = z2.Add(z2, nu)
+	w := modN0.Mul(r, modN0.Exp(rho, e))
+	return x, &DecProof{S: S, T: T, A: A, Gamma: gamma, Z1: z1, Z2: z2, W: w}, nil
+}
+
+func DecProofFromBytes(bzs [][]byte) (*DecProof, error) {
+	if len(bzs) != DecProofBytesParts {
+		return nil, fmt.Errorf("expected %d byte parts to construct DecProof", DecProofBytesParts)
+	}
+	// Gamma may legitimately be zero and serialise to an empty slice
+	return DecProofUnFlat(curve.MultiBytesToBigInts(bzs))
+}
+
+// VerifyDecryption checks that C decrypts to some y with y = x mod q
+func (publicKey *PublicKey) VerifyDecryption(ec elliptic.Curve, NCap, s, t, C, x *big.Int, pf *DecProof, optionalSession ...*cmt.Session) bool {
+	return publicKey.VerifyDecryptionWithReason(ec, NCap, s, t, C, x, pf, optionalSession...) == nil
+}
+
+// VerifyDecryptionWithReason is VerifyDecryption returning an *abort.VerifyError that names the failed check of CGGMP21 Fig. 30
+func (publicKey *PublicKey) VerifyDecryptionWithReason(ec elliptic.Curve, NCap, s, t, C, x *big.Int, pf *DecProof, optionalSession ...*cmt.Session) error {
+	const name = "DecProof"
+	if pf == nil || !pf.ValidateBasic() || ec == nil || NCap == nil || s == nil || t == nil || C == nil || x == nil {
+		return abort.NewMalformedError(name, "nil proof or statement value(s)")
+	}
+	q := ec.Params().N
+	N0, N0Squared := publicKey.N, publicKey.NSquare()
+	if !prime.IsInInterval(x, q) || !prime.IsInInterval(pf.Gamma, q) {
+		return abort.NewMalformedError(name, "x or gamma is not in [0, q)")
+	}
+	if !curve.IsNumberInMultiplicativeGroup(N0Squared, C) || !curve.IsNumberInMultiplicativeGroup(N0Squared, pf.A) {
+		return abort.NewMalformedError(name, "C or A is not in Z*_N0^2")
+	}
+	if !curve.IsNumberInMultiplicativeGroup(NCap, pf.S) || !curve.IsNumberInMultiplicativeGroup(NCap, pf.T) {
+		return abort.NewMalformedError(name, "S or T is not in Z*_NCap")
+	}
+	if !curve.IsNumberInMultiplicativeGroup(N0, pf.W) || pf.Z1.Sign() == -1 || pf.Z2.Sign() == -1 {
+		return abort.NewMalformedError(name, "w is not in Z*_N0 or z1, z2 is negative")
+	}
+
+	e := decChallenge(q, publicKey, NCap, s, t, C, x, pf.S, pf.T, pf.A, pf.Gamma, cmt.OptionalSession(optionalSession))
+
+	{ // (1+N0)^z1 * w^N0 = A * C^e mod N0^2
+		modN0Squared := prime.ModInt(N0Squared)
+		left := modN0Squared.Mul(modN0Squared.Exp(publicKey.Gamma(), pf.Z1), modN0Squared.Exp(pf.W, N0))
+		right := modN0Squared.Mul(pf.A, modN0Squared.Exp(C, e))
+		if left.Cmp(right) != 0 {
+			return abort.NewRejectedError(name, "equality check 1", "(1+N0)^z1 * w^N0 != A * C^e mod N0^2")
+		}
+	}
+
+	{ // z1 = gamma + e * x mod q
+		modQ := prime.ModInt(q)
+		left := new(big.Int).Mod(pf.Z1, q)
+		right := modQ.Add(pf.Gamma, modQ.Mul(e, x))
+		if left.Cmp(right) != 0 {
+			return abort.NewRejectedError(name, "equality check 2", "z1 != gamma + e * x mod q")
+		}
+	}
+
+	{ // s^z1 * t^z2 = T * S^e mod NCap
+		modNCap := prime.ModInt(NCap)
+		left := modNCap.Mul(modNCap.Exp(s, pf.Z1), modNCap.Exp(t, pf.Z2))
+		right := modNCap.Mul(pf.T, modNCap.Exp(pf.S, e))
+		if left.Cmp(right) != 0 {
+			return abort.NewRejectedError(name, "equality check 3", "s^z1 * t^z2 != T * S^e mod NCap")
+		}
+	}
+	return nil
+}
+
+func (pf *DecProof) ValidateBasic() bool {
+	return pf.S != nil &&
+		pf.T != nil &&
+		pf.A != nil &&
+		pf.Gamma != nil &&
+		pf.Z1 != nil &&
+		pf.Z2 != nil &&
+		pf.W != nil
+}
+
+func (pf *DecProof) Bytes() [DecProofBytesParts][]byte {
+	var out [DecProofBytesParts][]byte
+	for i, part := range pf.Flat() {
+		out[i] = part.Bytes()
+	}
+	return out
+}
+
+// ----- utils
+
+// recoverRandomness returns rho such that C = (1+N)^y * rho^N mod N2
+func (privateKey *PrivateKey) recoverRandomness(C, y *big.Int) (*big.Int, error) {
+	N, phiN := privateKey.N, privateKey.PhiN
+	if phiN == nil {
+		// keys carrying only P and Q are supported by DecryptCRT
+		if privateKey.P == nil || privateKey.Q == nil {
+			return nil, errors.New("recoverRandomness: the key carries neither phi(N) nor P and Q")
+		}
+		phiN = new(big.Int).Mul(new(big.Int).Sub(privateKey.P, one), new(big.Int).Sub(privateKey.Q, one))
+	}
+	NInv := new(big.Int).ModInverse(N, phiN)
+	if NInv == nil {
+		return nil, errors.New("recoverRandomness: N is not invertible mod phi(N)")
+	}
+	// C mod N = rho^N mod N
+	return new(big.Int).Exp(new(big.Int).Mod(C, N), NInv, N), nil
+}
+
+// decChallenge derives e for the claim that C decrypts to x mod q
+func decChallenge(q *big.Int, pk *PublicKey, NCap, s, t, C, x, S, T, A, gamma *big.Int, session *cmt.Session) *big.Int {
+	ch := &cmt.Challenge{
+		Domain:     "zk-proof/paillier/dec/v1",
+		Statement:  append(pk.AsInts(), NCap, s, t, C, x),
+		Commitment: []*big.Int{S, T, A, gamma},
+	}
+	return ch.Scalar(session, q)
+}
@@ -0,0 +1,45 @@
+package paillier
+
+import (
+	"fmt"
+	"math/big"
+)
+
+func (pf *ModProof) Flat() []*big.Int {
+	out := make([]*big.Int, 0, ModProofBytesParts)
+	out = append(out, pf.W, pf.A, pf.B)
+	out = append(out, pf.X[:]...)
+	out = append(out, pf.Z[:]...)
+	return out
+}
+
+func ModProofUnFlat(in []*big.Int) (*ModProof, error) {
+	if len(in) != ModProofBytesParts {
+		return nil, fmt.Errorf("expected %d big.Int parts to construct ModProof", ModProofBytesParts)
+	}
+	pf := &ModProof{W: in[0], A: in[1], B: in[2]}
+	copy(pf.X[:], in[3:3+ModProofIters])
+	copy(pf.Z[:], in[3+ModProofIters:])
+	return pf, nil
+}
+
+func (pf *DecProof) Flat() []*big.Int {
+	return []*big.Int{
+		pf.S,
+		pf.T,
+		pf.A,
+		pf.Gamma,
+		pf.Z1,
+		pf.Z2,
+		pf.W,
+	}
+}
+
+func DecProofUnFlat(in []*big.Int) (*DecProof, error) {
+	if len(in) != DecProofBytesParts {
+		return nil, fmt.Errorf("expected %d big.Int parts to construct DecProof", DecProofBytesParts)
+	}
+	return &DecProof{
+		S: in[0], T: in[1], A: in[2], Gamma: in[3], Z1: in[4], Z2: in[5], W: in[6],
+	}, nil
+}
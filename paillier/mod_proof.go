@@ -0,0 +1,200 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package paillier
+
+import (
+	"errors"
+	"fmt"
+	"github.com/zhp12543/zk-proof/abort"
+	"github.com/zhp12543/zk-proof/cmt"
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/prime"
+	gmath "math"
+	"math/big"
+)
+
+const (
+	ModProofIters      = 80
+	ModProofBytesParts = ModProofIters*2 + 3
+)
+
+type (
+	// ModProof is the Paillier-Blum modulus proof Πmod from CGGMP21 Fig. 16.
+	// It shows that N = pq with p, q = 3 mod 4 and gcd(N, phi(N)) = 1.
+	ModProof struct {
+		W  *big.Int
+		X  [ModProofIters]*big.Int
+		A, // bit i holds a_i
+		B *big.Int // bit i holds b_i
+		Z [ModProofIters]*big.Int
+	}
+)
+
+// ModProof proves that the key modulus is a Paillier-Blum modulus.
+// The safe primes produced by GenerateKeyPair are always = 3 mod 4.
+func (privateKey *PrivateKey) ModProof(optionalSession ...*cmt.Session) (*ModProof, error) {
+	N, P, Q := privateKey.N, privateKey.P, privateKey.Q
+	if P == nil || Q == nil {
+		return nil, errors.New("ModProof: the key does not carry P and Q")
+	}
+	if P.Bit(0) != 1 || P.Bit(1) != 1 || Q.Bit(0) != 1 || Q.Bit(1) != 1 {
+		return nil, errors.New("ModProof: P and Q must be = 3 mod 4")
+	}
+	phiN := new(big.Int).Mul(new(big.Int).Sub(P, one), new(big.Int).Sub(Q, one))
+	modN, modP, modQ := prime.ModInt(N), prime.ModInt(P), prime.ModInt(Q)
+
+	// 1. W is a random element with Jacobi symbol -1
+	var W *big.Int
+	for {
+		W = curve.GetRandomPositiveRelativelyPrimeInt(N)
+		if big.Jacobi(W, N) == -1 {
+			break
+		}
+	}
+
+	// 2. y_i
+	Y := modProofChallenges(N, W, cmt.OptionalSession(optionalSession))
+
+	// 3.
+	NInv := new(big.Int).ModInverse(N, phiN)
+	if NInv == nil {
+		return nil, errors.New("ModProof: N is not invertible mod phi(N)")
+	}
+	// fourth roots of a quadratic residue mod a Blum prime p are obtained by raising to ((p+1)/4)^2
+	expP := new(big.Int).Rsh(new(big.Int).Add(P, one), 2)
+	expP = expP.Mul(expP, expP)
+	expQ := new(big.Int).Rsh(new(big.Int).Add(Q, one), 2)
+	expQ = expQ.Mul(expQ, expQ)
+	PInvQ := new(big.Int).ModInverse(P, Q)
+	minusOne := new(big.Int).Sub(N, one)
+
+	pf := &ModProof{W: W, A: new(big.Int), B: new(big.Int)}
+	for i := range Y {
+		for j := 0; j < 4; j++ {
+			a, b := j&1, (j&2)>>1
+			yi := new(big.Int).Set(Y[i])
+			if a == 1 {
+				yi = modN.Mul(yi, minusOne)
+			}
+			if b == 1 {
+				yi = modN.Mul(yi, W)
+			}
+			if big.Jacobi(yi, P) != 1 || big.Jacobi(yi, Q) != 1 {
+				continue
+			}
+			xp := modP.Exp(yi, expP)
+			xq := modQ.Exp(yi, expQ)
+			// CRT: x = xp + p * ((xq - xp) * p^-1 mod q)
+			h := modQ.Mul(new(big.Int).Sub(xq, xp), PInvQ)
+			pf.X[i] = h.Mul(h, P).Add(h, xp)
+			pf.A.SetBit(pf.A, i, uint(a))
+			pf.B.SetBit(pf.B, i, uint(b))
+			break
+		}
+		if pf.X[i] == nil {
+			return nil, errors.New("ModProof: could not find a fourth root")
+		}
+		pf.Z[i] = modN.Exp(Y[i], NInv)
+	}
+	return pf, nil
+}
+
+func ModProofFromBytes(bzs [][]byte) (*ModProof, error) {
+	if !curve.NonEmptyMultiBytes(bzs, ModProofBytesParts) {
+		return nil, fmt.Errorf("expected %d byte parts to construct ModProof", ModProofBytesParts)
+	}
+	return ModProofUnFlat(curve.MultiBytesToBigInts(bzs))
+}
+
+func (pf *ModProof) Verify(N *big.Int, optionalSession ...*cmt.Session) bool {
+	return pf.VerifyWithReason(N, optionalSession...) == nil
+}
+
+// VerifyWithReason is Verify returning an *abort.VerifyError that names the failed check of CGGMP21 Fig. 16
+func (pf *ModProof) VerifyWithReason(N *big.Int, optionalSession ...*cmt.Session) error {
+	const name = "ModProof"
+	if pf == nil || !pf.ValidateBasic() || N == nil {
+		return abort.NewMalformedError(name, "nil proof or statement value(s)")
+	}
+	// N must be odd and composite
+	if N.Sign() != 1 || N.Bit(0) != 1 || N.ProbablyPrime(30) {
+		return abort.NewRejectedError(name, "odd composite check", "N is not an odd composite number")
+	}
+	if !curve.IsNumberInMultiplicativeGroup(N, pf.W) || big.Jacobi(pf.W, N) != -1 {
+		return abort.NewMalformedError(name, "w is not in Z*_N with Jacobi symbol -1")
+	}
+	if pf.A.Sign() == -1 || pf.A.BitLen() > ModProofIters || pf.B.Sign() == -1 || pf.B.BitLen() > ModProofIters {
+		return abort.NewMalformedError(name, "a or b has more than ModProofIters bits")
+	}
+	for i := 0; i < ModProofIters; i++ {
+		if !curve.IsNumberInMultiplicativeGroup(N, pf.X[i]) || !curve.IsNumberInMultiplicativeGroup(N, pf.Z[i]) {
+			return abort.NewMalformedError(name, "an x_i or z_i is not in Z*_N")
+		}
+	}
+
+	Y := modProofChallenges(N, pf.W, cmt.OptionalSession(optionalSession))
+	modN := prime.ModInt(N)
+	minusOne := new(big.Int).Sub(N, one)
+	four := big.NewInt(4)
+	for i := range Y {
+		// z_i^N = y_i mod N
+		if modN.Exp(pf.Z[i], N).Cmp(Y[i]) != 0 {
+			return abort.NewRejectedError(name, fmt.Sprintf("iteration %d", i), "z_i^N != y_i mod N")
+		}
+		// x_i^4 = (-1)^a_i * w^b_i * y_i mod N
+		right := new(big.Int).Set(Y[i])
+		if pf.A.Bit(i) == 1 {
+			right = modN.Mul(right, minusOne)
+		}
+		if pf.B.Bit(i) == 1 {
+			right = modN.Mul(right, pf.W)
+		}
+		if modN.Exp(pf.X[i], four).Cmp(right) != 0 {
+			return abort.NewRejectedError(name, fmt.Sprintf("iteration %d", i), "x_i^4 != (-1)^a_i * w^b_i * y_i mod N")
+		}
+	}
+	return nil
+}
+
+func (pf *ModProof) ValidateBasic() bool {
+	if pf.W == nil || pf.A == nil || pf.B == nil {
+		return false
+	}
+	for i := 0; i < ModProofIters; i++ {
+		if pf.X[i] == nil || pf.Z[i] == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (pf *ModProof) Bytes() [ModProofBytesParts][]byte {
+	var out [ModProofBytesParts][]byte
+	for i, part := range pf.Flat() {
+		out[i] = part.Bytes()
+	}
+	return out
+}
+
+// ----- utils
+
+// modProofChallenges derives the y_i in Z*_N from N and W, expanding SHA512_256 blocks to the size of N like GenerateXs
+func modProofChallenges(N, W *big.Int, session *cmt.Session) [ModProofIters]*big.Int {
+	var ret [ModProofIters]*big.Int
+	blocks := int(gmath.Ceil(float64(N.BitLen()) / 256))
+	ch := &cmt.Challenge{Domain: "zk-proof/paillier/mod/v1", Statement: []*big.Int{N, W}}
+	next := ch.Candidates(session, blocks*32)
+	for i, n := 0, 0; i < ModProofIters; n++ {
+		y := new(big.Int).SetBytes(next(i, n))
+		y = y.Mod(y, N)
+		if curve.IsNumberInMultiplicativeGroup(N, y) {
+			ret[i] = y
+			i++
+		}
+	}
+	return ret
+}
@@ -17,16 +17,18 @@ package paillier
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
 	"github.com/otiai10/primes"
 	"github.com/zhp12543/zk-proof/cmt"
 	"github.com/zhp12543/zk-proof/curve"
 	"github.com/zhp12543/zk-proof/prime"
+	"io"
 	gmath "math"
 	"math/big"
 	"runtime"
-	"strconv"
+	"sync"
 )
 
 const (
@@ -45,6 +47,12 @@ type (
 		LambdaN, // lcm(p-1, q-1)
 		PhiN *big.Int // (p-1) * (q-1)
 		P, Q *big.Int
+
+		// derived on first use, see crt.go
+		muOnce sync.Once
+		crtMtx sync.Mutex // not a sync.Once: P and Q may be set after a first call without them
+		mu     *big.Int
+		crt    *PrecomputedValues
 	}
 
 	// Proof uses the new GenerateXs method in GG18Spec (6)
@@ -52,8 +60,9 @@ type (
 )
 
 var (
-	ErrMessageTooLong   = fmt.Errorf("the message is too large or < 0")
-	ErrMessageMalFormed = fmt.Errorf("the message is mal-formed")
+	ErrMessageTooLong      = fmt.Errorf("the message is too large or < 0")
+	ErrMessageMalFormed    = fmt.Errorf("the message is mal-formed")
+	ErrRandomnessMalFormed = fmt.Errorf("the randomness is not in Z*_N")
 
 	zero = big.NewInt(0)
 	one  = big.NewInt(1)
@@ -104,16 +113,36 @@ func GenerateKeyPair(ctx context.Context, modulusBitLen int, optionalConcurrency
 
 	publicKey = &PublicKey{N: N}
 	privateKey = &PrivateKey{PublicKey: *publicKey, LambdaN: lambdaN, PhiN: phiN, P: P, Q: Q}
+	privateKey.Precompute()
 	return
 }
 
 // ----- //
 
 func (publicKey *PublicKey) EncryptAndReturnRandomness(m *big.Int) (c *big.Int, x *big.Int, err error) {
+	return publicKey.EncryptAndReturnRandomnessWithReader(rand.Reader, m)
+}
+
+// EncryptAndReturnRandomnessWithReader draws the randomness x from `reader`, so that encryptions can be replayed from a deterministic source
+func (publicKey *PublicKey) EncryptAndReturnRandomnessWithReader(reader io.Reader, m *big.Int) (c *big.Int, x *big.Int, err error) {
 	if m.Cmp(zero) == -1 || m.Cmp(publicKey.N) != -1 { // m < 0 || m >= N ?
 		return nil, nil, ErrMessageTooLong
 	}
-	x = curve.GetRandomPositiveRelativelyPrimeInt(publicKey.N)
+	if x, err = curve.GetRandomPositiveRelativelyPrimeIntWithReader(reader, publicKey.N); err != nil {
+		return nil, nil, err
+	}
+	c, err = publicKey.EncryptWithRandomness(m, x)
+	return
+}
+
+// EncryptWithRandomness encrypts m using the caller-supplied randomness x, which must be in Z*_N
+func (publicKey *PublicKey) EncryptWithRandomness(m, x *big.Int) (c *big.Int, err error) {
+	if m.Cmp(zero) == -1 || m.Cmp(publicKey.N) != -1 { // m < 0 || m >= N ?
+		return nil, ErrMessageTooLong
+	}
+	if !curve.IsNumberInMultiplicativeGroup(publicKey.N, x) {
+		return nil, ErrRandomnessMalFormed
+	}
 	N2 := publicKey.NSquare()
 	// 1. gamma^m mod N2
 	Gm := new(big.Int).Exp(publicKey.Gamma(), m, N2)
@@ -180,10 +209,9 @@ func (privateKey *PrivateKey) Decrypt(c *big.Int) (m *big.Int, err error) {
 	}
 	// 1. L(u) = (c^LambdaN-1 mod N2) / N
 	Lc := L(new(big.Int).Exp(c, privateKey.LambdaN, N2), privateKey.N)
-	// 2. L(u) = (Gamma^LambdaN-1 mod N2) / N
-	Lg := L(new(big.Int).Exp(privateKey.Gamma(), privateKey.LambdaN, N2), privateKey.N)
-	// 3. (1) * modInv(2) mod N
-	inv := new(big.Int).ModInverse(Lg, privateKey.N)
+	// 2. mu = modInv(L(Gamma^LambdaN-1 mod N2) / N), cached on the key
+	inv := privateKey.getMu()
+	// 3. (1) * (2) mod N
 	m = prime.ModInt(privateKey.N).Mul(Lc, inv)
 	return
 }
@@ -193,11 +221,13 @@ func (privateKey *PrivateKey) Decrypt(c *big.Int) (m *big.Int, err error) {
 // Proof is an implementation of Gennaro, R., Micciancio, D., Rabin, T.:
 // An efficient non-interactive statistical zero-knowledge proof system for quasi-safe prime products.
 // In: In Proc. of the 5th ACM Conference on Computer and Communications Security (CCS-98. Citeseer (1998)
+//
+// It only shows gcd(N, phi(N)) = 1; protocols following CGGMP21 should use ModProof instead.
 
-func (privateKey *PrivateKey) Proof(k *big.Int, ecdsaPub *curve.ECPoint) Proof {
+func (privateKey *PrivateKey) Proof(k *big.Int, ecdsaPub *curve.ECPoint, optionalSession ...*cmt.Session) Proof {
 	var pi Proof
 	iters := ProofIters
-	xs := GenerateXs(iters, k, privateKey.N, ecdsaPub)
+	xs := GenerateXs(iters, k, privateKey.N, ecdsaPub, optionalSession...)
 	for i := 0; i < iters; i++ {
 		M := new(big.Int).ModInverse(privateKey.N, privateKey.PhiN)
 		pi[i] = new(big.Int).Exp(xs[i], M, privateKey.N)
@@ -205,7 +235,7 @@ func (privateKey *PrivateKey) Proof(k *big.Int, ecdsaPub *curve.ECPoint) Proof {
 	return pi
 }
 
-func (pf Proof) Verify(pkN, k *big.Int, ecdsaPub *curve.ECPoint) (bool, error) {
+func (pf Proof) Verify(pkN, k *big.Int, ecdsaPub *curve.ECPoint, optionalSession ...*cmt.Session) (bool, error) {
 	iters := ProofIters
 	pch, xch := make(chan bool, 1), make(chan []*big.Int, 1) // buffered to allow early exit
 	prms := primes.Until(verifyPrimesUntil).List()           // uses cache primed in init()
@@ -220,7 +250,7 @@ func (pf Proof) Verify(pkN, k *big.Int, ecdsaPub *curve.ECPoint) (bool, error) {
 		ch <- true
 	}(pch)
 	go func(ch chan<- []*big.Int) {
-		ch <- GenerateXs(iters, k, pkN, ecdsaPub)
+		ch <- GenerateXs(iters, k, pkN, ecdsaPub, optionalSession...)
 	}(xch)
 	for j := 0; j < 2; j++ {
 		select {
@@ -251,37 +281,16 @@ func L(u, N *big.Int) *big.Int {
 	return new(big.Int).Div(t, N)
 }
 
-// GenerateXs generates the challenges used in Paillier key Proof
-func GenerateXs(m int, k, N *big.Int, ecdsaPub *curve.ECPoint) []*big.Int {
+// GenerateXs generates the challenges used in Paillier key Proof, bound to an optional cmt.Session
+func GenerateXs(m int, k, N *big.Int, ecdsaPub *curve.ECPoint, optionalSession ...*cmt.Session) []*big.Int {
 	var i, n int
 	ret := make([]*big.Int, m)
-	sX, sY := ecdsaPub.X(), ecdsaPub.Y()
-	kb, sXb, sYb, Nb := k.Bytes(), sX.Bytes(), sY.Bytes(), N.Bytes()
 	bits := N.BitLen()
 	blocks := int(gmath.Ceil(float64(bits) / 256))
-	chs := make([]chan []byte, blocks)
-	for k := range chs {
-		chs[k] = make(chan []byte)
-	}
+	ch := &cmt.Challenge{Domain: "zk-proof/paillier/xs/v1", Statement: []*big.Int{k, ecdsaPub.X(), ecdsaPub.Y(), N}}
+	next := ch.Candidates(cmt.OptionalSession(optionalSession), blocks*32)
 	for i < m {
-		xi := make([]byte, 0, blocks*32)
-		ib := []byte(strconv.Itoa(i))
-		nb := []byte(strconv.Itoa(n))
-		for j := 0; j < blocks; j++ {
-			go func(j int) {
-				jBz := []byte(strconv.Itoa(j))
-				hash := cmt.SHA512_256(ib, jBz, nb, kb, sXb, sYb, Nb)
-				chs[j] <- hash
-			}(j)
-		}
-		for _, ch := range chs { // must be in order
-			rx := <-ch
-			if rx == nil { // this should never happen. see: https://golang.org/pkg/hash/#Hash
-				panic(errors.New("GenerateXs hash write error!"))
-			}
-			xi = append(xi, rx...) // xi1||···||xib
-		}
-		ret[i] = new(big.Int).SetBytes(xi)
+		ret[i] = new(big.Int).SetBytes(next(i, n)) // xi1||···||xib
 		if curve.IsNumberInMultiplicativeGroup(N, ret[i]) {
 			i++
 		} else {
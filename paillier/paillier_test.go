@@ -0,0 +1,583 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package paillier
+
+import (
+	"bytes"
+	"context"
+	"crypto/elliptic"
+	"errors"
+	"github.com/zhp12543/zk-proof/abort"
+	"github.com/zhp12543/zk-proof/cmt"
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/prime"
+	"math/big"
+	mrand "math/rand"
+	"testing"
+	"time"
+)
+
+// Using a small modulus keeps key generation fast; the arithmetic is identical at 2048 bits
+const testPaillierKeyLength = 1024
+
+var (
+	privateKey *PrivateKey
+	publicKey  *PublicKey
+)
+
+func setUp(t *testing.T) {
+	if privateKey != nil && publicKey != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	var err error
+	privateKey, publicKey, err = GenerateKeyPair(ctx, testPaillierKeyLength)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDecryptCRT(t *testing.T) {
+	setUp(t)
+	// a key without cached values, as after JSON decoding
+	bare := &PrivateKey{PublicKey: privateKey.PublicKey, LambdaN: privateKey.LambdaN, PhiN: privateKey.PhiN, P: privateKey.P, Q: privateKey.Q}
+	// a key without P and Q falls back to Decrypt
+	noPQ := &PrivateKey{PublicKey: privateKey.PublicKey, LambdaN: privateKey.LambdaN, PhiN: privateKey.PhiN}
+
+	msgs := []*big.Int{big.NewInt(0), big.NewInt(1), new(big.Int).Sub(publicKey.N, one)}
+	for i := 0; i < 32; i++ {
+		msgs = append(msgs, curve.GetRandomPositiveInt(publicKey.N))
+	}
+	for _, m := range msgs {
+		c, err := publicKey.Encrypt(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := bare.Decrypt(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected.Cmp(m) != 0 {
+			t.Fatalf("Decrypt() = %v, want %v", expected, m)
+		}
+		for _, sk := range []*PrivateKey{privateKey, bare, noPQ} {
+			got, err := sk.DecryptCRT(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Cmp(expected) != 0 {
+				t.Fatalf("DecryptCRT() = %v, Decrypt() = %v", got, expected)
+			}
+		}
+	}
+	if pv := bare.PrecomputedValues(); pv == nil || pv != bare.PrecomputedValues() {
+		t.Error("expected the precomputed values to be cached on first use")
+	}
+	if noPQ.PrecomputedValues() != nil {
+		t.Error("expected no precomputed values for a key without P and Q")
+	}
+	// P and Q set after a first call without them are picked up
+	noPQ.P, noPQ.Q = privateKey.P, privateKey.Q
+	if noPQ.PrecomputedValues() == nil {
+		t.Fatal("expected precomputed values once P and Q are set")
+	}
+	c, err := publicKey.Encrypt(msgs[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := noPQ.DecryptCRT(c); err != nil || got.Cmp(msgs[3]) != 0 {
+		t.Errorf("DecryptCRT() = %v, %v after setting P and Q", got, err)
+	}
+}
+
+func TestDecryptCRTRejectsInvalid(t *testing.T) {
+	setUp(t)
+	if _, err := privateKey.DecryptCRT(big.NewInt(-1)); err != ErrMessageTooLong {
+		t.Errorf("expected ErrMessageTooLong, got %v", err)
+	}
+	if _, err := privateKey.DecryptCRT(publicKey.NSquare()); err != ErrMessageTooLong {
+		t.Errorf("expected ErrMessageTooLong, got %v", err)
+	}
+	if _, err := privateKey.DecryptCRT(privateKey.P); err != ErrMessageMalFormed {
+		t.Errorf("expected ErrMessageMalFormed, got %v", err)
+	}
+}
+
+func TestEncryptWithRandomness(t *testing.T) {
+	setUp(t)
+	m := curve.GetRandomPositiveInt(publicKey.N)
+	c1, x, err := publicKey.EncryptAndReturnRandomness(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := publicKey.EncryptWithRandomness(m, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c1.Cmp(c2) != 0 {
+		t.Fatal("EncryptWithRandomness() did not reproduce the ciphertext")
+	}
+	for _, bad := range []*big.Int{big.NewInt(0), publicKey.N, privateKey.P, new(big.Int).Add(publicKey.N, one)} {
+		if _, err := publicKey.EncryptWithRandomness(m, bad); err != ErrRandomnessMalFormed {
+			t.Errorf("expected ErrRandomnessMalFormed for %v, got %v", bad, err)
+		}
+	}
+}
+
+func TestEncryptAndReturnRandomnessWithReader(t *testing.T) {
+	setUp(t)
+	m := curve.GetRandomPositiveInt(publicKey.N)
+	c1, x1, err := publicKey.EncryptAndReturnRandomnessWithReader(mrand.New(mrand.NewSource(42)), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, x2, err := publicKey.EncryptAndReturnRandomnessWithReader(mrand.New(mrand.NewSource(42)), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c1.Cmp(c2) != 0 || x1.Cmp(x2) != 0 {
+		t.Fatal("encryptions from the same seed differ")
+	}
+	if _, _, err := publicKey.EncryptAndReturnRandomnessWithReader(bytes.NewReader(nil), m); err == nil {
+		t.Fatal("expected an error from an exhausted reader")
+	}
+}
+
+func TestRandomnessPool(t *testing.T) {
+	setUp(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	pool, err := publicKey.NewRandomnessPool(ctx, 8, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for pool.Stats().Available < 8 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	seen := make(map[string]bool)
+	for i := 0; i < 24; i++ {
+		m := curve.GetRandomPositiveInt(publicKey.N)
+		c, x, err := pool.EncryptAndReturnRandomness(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[x.String()] {
+			t.Fatal("randomness pair reused")
+		}
+		seen[x.String()] = true
+		expected, err := publicKey.EncryptWithRandomness(m, x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Cmp(expected) != 0 {
+			t.Fatal("pooled encryption differs from EncryptWithRandomness()")
+		}
+	}
+	cancel()
+	pool.Wait()
+	stats := pool.Stats()
+	if stats.Size != 8 || stats.Consumed+stats.Misses != 24 || stats.Generated < stats.Consumed {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	if _, err := pool.Encrypt(publicKey.N); err != ErrMessageTooLong {
+		t.Errorf("expected ErrMessageTooLong, got %v", err)
+	}
+}
+
+func TestSignedHomomorphicOps(t *testing.T) {
+	setUp(t)
+	half := new(big.Int).Rsh(publicKey.N, 1)
+	negHalf := new(big.Int).Neg(half)
+	m1 := new(big.Int).Neg(curve.GetRandomPositiveInt(new(big.Int).Rsh(half, 1)))
+	m2 := curve.GetRandomPositiveInt(new(big.Int).Rsh(half, 4))
+
+	for _, m := range []*big.Int{m1, m2, half, negHalf, big.NewInt(0)} {
+		c, err := publicKey.EncryptSigned(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := privateKey.DecryptSigned(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Cmp(m) != 0 {
+			t.Fatalf("DecryptSigned() = %v, want %v", got, m)
+		}
+	}
+	for _, m := range []*big.Int{new(big.Int).Add(half, one), new(big.Int).Sub(negHalf, one)} {
+		if _, err := publicKey.EncodeSigned(m); err != ErrMessageTooLong {
+			t.Errorf("expected ErrMessageTooLong for %v, got %v", m, err)
+		}
+	}
+
+	c1, _ := publicKey.EncryptSigned(m1)
+	c2, _ := publicKey.EncryptSigned(m2)
+	expect := func(c, m *big.Int) {
+		t.Helper()
+		got, err := privateKey.DecryptSigned(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Cmp(m) != 0 {
+			t.Fatalf("got %v, want %v", got, m)
+		}
+	}
+	sub, err := publicKey.HomoSub(c1, c2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect(sub, new(big.Int).Sub(m1, m2))
+	neg, err := publicKey.HomoNeg(c1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect(neg, new(big.Int).Neg(m1))
+	k := big.NewInt(-7)
+	mul, err := publicKey.HomoMultSigned(k, c2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect(mul, new(big.Int).Mul(k, m2))
+	add, err := publicKey.HomoAddPlaintextSigned(c1, m2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect(add, new(big.Int).Add(m1, m2))
+	if _, err := publicKey.HomoNeg(privateKey.P); err != ErrMessageMalFormed {
+		t.Errorf("expected ErrMessageMalFormed, got %v", err)
+	}
+}
+
+func TestCiphertext(t *testing.T) {
+	setUp(t)
+	m1, m2 := big.NewInt(1000), big.NewInt(234)
+	ct1, _, err := publicKey.EncryptToCiphertext(m1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := publicKey.Encrypt(m2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct2, err := publicKey.NewCiphertext(c2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum, err := ct1.Add(ct2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff, err := ct1.Sub(ct2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr, _, err := ct1.Rerandomize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rr.Equals(ct1) {
+		t.Fatal("Rerandomize() returned the same ciphertext")
+	}
+	for ct, want := range map[*Ciphertext]int64{sum: 1234, diff: 766, rr: 1000} {
+		got, err := privateKey.DecryptCiphertext(ct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Int64() != want {
+			t.Fatalf("got %v, want %d", got, want)
+		}
+	}
+
+	for _, bad := range []*big.Int{big.NewInt(0), publicKey.NSquare(), privateKey.P, big.NewInt(-1)} {
+		if _, err := publicKey.NewCiphertext(bad); err != ErrCiphertextMalFormed {
+			t.Errorf("expected ErrCiphertextMalFormed for %v, got %v", bad, err)
+		}
+	}
+	otherPK := &PublicKey{N: new(big.Int).Add(publicKey.N, big.NewInt(2))}
+	other, err := otherPK.NewCiphertext(big.NewInt(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ct1.Add(other); err != ErrCiphertextKeyMismatch {
+		t.Errorf("expected ErrCiphertextKeyMismatch, got %v", err)
+	}
+	if _, err := privateKey.DecryptCiphertext(other); err != ErrCiphertextKeyMismatch {
+		t.Errorf("expected ErrCiphertextKeyMismatch, got %v", err)
+	}
+}
+
+func TestDamgardJurik(t *testing.T) {
+	setUp(t)
+	for _, s := range []int{1, 2, 3} {
+		djSK, err := privateKey.DamgardJurik(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		djPK := &djSK.DJPublicKey
+		NS := djPK.NS()
+		m1 := curve.GetRandomPositiveInt(NS)
+		m2 := curve.GetRandomPositiveInt(NS)
+		c1, err := djPK.Encrypt(m1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c2, err := djPK.Encrypt(m2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := djSK.Decrypt(c1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Cmp(m1) != 0 {
+			t.Fatalf("s=%d: Decrypt() = %v, want %v", s, got, m1)
+		}
+		if s == 1 {
+			paiM, err := privateKey.Decrypt(c1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if paiM.Cmp(m1) != 0 {
+				t.Fatal("s=1 ciphertext is not a Paillier ciphertext")
+			}
+		}
+		sum, err := djPK.HomoAdd(c1, c2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		k := big.NewInt(12345)
+		prod, err := djPK.HomoMult(k, sum)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err = djSK.Decrypt(prod)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := new(big.Int).Add(m1, m2)
+		want = want.Mul(want, k)
+		want = want.Mod(want, NS)
+		if got.Cmp(want) != 0 {
+			t.Fatalf("s=%d: homomorphic result = %v, want %v", s, got, want)
+		}
+		if _, err := djPK.Encrypt(NS); err != ErrMessageTooLong {
+			t.Errorf("expected ErrMessageTooLong, got %v", err)
+		}
+	}
+}
+
+func TestThresholdDecryption(t *testing.T) {
+	setUp(t)
+	tpk, shares, err := privateKey.DealThreshold(3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := curve.GetRandomPositiveInt(publicKey.N)
+	c, err := publicKey.Encrypt(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dss := make([]*DecryptionShare, 0, 3)
+	for _, i := range []int{5, 2, 4} {
+		ds, err := shares[i-1].PartialDecrypt(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !tpk.VerifyDecryptionShare(c, ds) {
+			t.Fatalf("decryption share %d did not verify", i)
+		}
+		dss = append(dss, ds)
+	}
+	got, err := tpk.Combine(c, dss)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Cmp(m) != 0 {
+		t.Fatalf("Combine() = %v, want %v", got, m)
+	}
+
+	if _, err := tpk.Combine(c, dss[:2]); err == nil {
+		t.Error("expected an error with fewer than threshold shares")
+	}
+	bad := &DecryptionShare{Index: dss[1].Index, Ci: new(big.Int).Add(dss[1].Ci, one), Proof: dss[1].Proof}
+	if _, err := tpk.Combine(c, []*DecryptionShare{dss[0], bad, dss[2]}); err == nil {
+		t.Error("expected an error with a tampered share")
+	}
+	if _, err := tpk.Combine(c, []*DecryptionShare{dss[0], dss[0], dss[2]}); err == nil {
+		t.Error("expected an error with a duplicate share")
+	}
+
+	// with more than threshold shares the invalid and duplicate ones are skipped
+	extra, err := shares[0].PartialDecrypt(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = tpk.Combine(c, []*DecryptionShare{bad, dss[0], nil, dss[0], dss[2], extra})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Cmp(m) != 0 {
+		t.Fatalf("Combine() = %v, want %v", got, m)
+	}
+
+	// a share bound to a session only verifies in that session
+	session, _ := cmt.NewSession([]byte("session"), big.NewInt(1))
+	other, _ := cmt.NewSession([]byte("other session"), big.NewInt(1))
+	ds, err := shares[1].PartialDecrypt(c, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tpk.VerifyDecryptionShare(c, ds, session) {
+		t.Fatal("decryption share did not verify in its session")
+	}
+	if tpk.VerifyDecryptionShare(c, ds, other) || tpk.VerifyDecryptionShare(c, ds) {
+		t.Error("decryption share verified outside its session")
+	}
+}
+
+func TestModProof(t *testing.T) {
+	setUp(t)
+	pf, err := privateKey.ModProof()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pf.Verify(publicKey.N) {
+		t.Fatal("ModProof did not verify")
+	}
+	bzs := pf.Bytes()
+	pf2, err := ModProofFromBytes(bzs[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pf2.Verify(publicKey.N) {
+		t.Fatal("ModProof did not verify after a bytes round trip")
+	}
+	pf3, err := ModProofUnFlat(pf.Flat())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pf3.Verify(publicKey.N) {
+		t.Fatal("ModProof did not verify after a flat round trip")
+	}
+
+	otherN := new(big.Int).Add(publicKey.N, big.NewInt(2))
+	if pf.Verify(otherN) {
+		t.Error("ModProof verified against another modulus")
+	}
+	pf3.Z[7] = new(big.Int).Add(pf3.Z[7], one)
+	if pf3.Verify(publicKey.N) {
+		t.Error("tampered ModProof verified")
+	}
+	var ve *abort.VerifyError
+	if err := pf3.VerifyWithReason(publicKey.N); !errors.As(err, &ve) || ve.Kind != abort.Rejected || ve.Step != "iteration 7" {
+		t.Errorf("expected iteration 7 to be rejected, got %v", err)
+	}
+	if err := pf.VerifyWithReason(privateKey.P); !abort.IsRejected(err) {
+		t.Errorf("expected a prime modulus to be rejected, got %v", err)
+	}
+
+	session, _ := cmt.NewSession([]byte("session"), big.NewInt(1))
+	other, _ := cmt.NewSession([]byte("session"), big.NewInt(2))
+	pf, err = privateKey.ModProof(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pf.Verify(publicKey.N, session) {
+		t.Fatal("ModProof did not verify in its session")
+	}
+	if pf.Verify(publicKey.N, other) || pf.Verify(publicKey.N) {
+		t.Error("ModProof verified outside its session")
+	}
+}
+
+func TestDecProof(t *testing.T) {
+	setUp(t)
+	ec := elliptic.P256()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	sgps, err := prime.GetRandomSafePrimesConcurrent(ctx, testPaillierKeyLength/2, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	NCap := new(big.Int).Mul(sgps[0].SafePrime(), sgps[1].SafePrime())
+	f := curve.GetRandomPositiveRelativelyPrimeInt(NCap)
+	tt := prime.ModInt(NCap).Mul(f, f)
+	s := prime.ModInt(NCap).Exp(tt, curve.GetRandomPositiveRelativelyPrimeInt(NCap))
+
+	m := curve.GetRandomPositiveInt(publicKey.N)
+	C, err := publicKey.Encrypt(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, pf, err := privateKey.ProveDecryption(ec, NCap, s, tt, C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.Cmp(new(big.Int).Mod(m, ec.Params().N)) != 0 {
+		t.Fatal("ProveDecryption() returned the wrong plaintext")
+	}
+	if !publicKey.VerifyDecryption(ec, NCap, s, tt, C, x, pf) {
+		t.Fatal("DecProof did not verify")
+	}
+	bzs := pf.Bytes()
+	pf2, err := DecProofFromBytes(bzs[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !publicKey.VerifyDecryption(ec, NCap, s, tt, C, x, pf2) {
+		t.Fatal("DecProof did not verify after a bytes round trip")
+	}
+	wrongX := new(big.Int).Add(x, one)
+	if publicKey.VerifyDecryption(ec, NCap, s, tt, C, wrongX, pf) {
+		t.Error("DecProof verified for a wrong plaintext")
+	}
+	otherC, err := publicKey.Encrypt(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if publicKey.VerifyDecryption(ec, NCap, s, tt, otherC, x, pf) {
+		t.Error("DecProof verified for another ciphertext")
+	}
+	bad := *pf
+	bad.Gamma = ec.Params().N
+	if err := publicKey.VerifyDecryptionWithReason(ec, NCap, s, tt, C, x, &bad); !abort.IsMalformed(err) {
+		t.Errorf("expected gamma >= q to be malformed, got %v", err)
+	}
+	bad = *pf
+	bad.Z2 = new(big.Int).Add(pf.Z2, one)
+	var ve *abort.VerifyError
+	if err := publicKey.VerifyDecryptionWithReason(ec, NCap, s, tt, C, x, &bad); !errors.As(err, &ve) || ve.Step != "equality check 3" {
+		t.Errorf("expected equality check 3 to fail, got %v", err)
+	}
+
+	// phi(N) is derived from P and Q when the key does not carry it
+	noPhi := &PrivateKey{PublicKey: privateKey.PublicKey, LambdaN: privateKey.LambdaN, P: privateKey.P, Q: privateKey.Q}
+	x, pf, err = noPhi.ProveDecryption(ec, NCap, s, tt, C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !publicKey.VerifyDecryption(ec, NCap, s, tt, C, x, pf) {
+		t.Fatal("DecProof from a key without phi(N) did not verify")
+	}
+	noPQ := &PrivateKey{PublicKey: privateKey.PublicKey, LambdaN: privateKey.LambdaN}
+	if _, _, err := noPQ.ProveDecryption(ec, NCap, s, tt, C); err == nil {
+		t.Error("expected an error for a key without phi(N), P and Q")
+	}
+
+	session, _ := cmt.NewSession([]byte("session"), big.NewInt(1))
+	other, _ := cmt.NewSession([]byte("session"), big.NewInt(2))
+	x, pf, err = privateKey.ProveDecryption(ec, NCap, s, tt, C, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !publicKey.VerifyDecryption(ec, NCap, s, tt, C, x, pf, session) {
+		t.Fatal("DecProof did not verify in its session")
+	}
+	if publicKey.VerifyDecryption(ec, NCap, s, tt, C, x, pf, other) || publicKey.VerifyDecryption(ec, NCap, s, tt, C, x, pf) {
+		t.Error("DecProof verified outside its session")
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package paillier
+
+import (
+	"context"
+	"errors"
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/prime"
+	"math/big"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+type (
+	// RandomnessPair is an encryption randomness r in Z*_N together with r^N mod N2
+	RandomnessPair struct {
+		R, RN *big.Int
+	}
+
+	// RandomnessPool precomputes RandomnessPairs in the background so that an online encryption costs one
+	// multiplication mod N2. Each pair is handed out at most once.
+	RandomnessPool struct {
+		publicKey *PublicKey
+		pairs     chan *RandomnessPair
+		wg        sync.WaitGroup
+
+		generated, consumed, misses uint64
+	}
+
+	// RandomnessPoolStats is a snapshot of the pool counters
+	RandomnessPoolStats struct {
+		Size,
+		Available int
+		Generated, // pairs computed by the background workers
+		Consumed, // pairs handed out from the pool
+		Misses uint64 // pairs computed inline because the pool was empty
+	}
+)
+
+// NewRandomnessPool starts workers that keep up to `size` pairs ready until `ctx` is done.
+// Pairs that are already pooled remain usable after the context is done; afterwards pairs are computed inline.
+// If not specified, a concurrency value equal to the number of available CPU cores will be used.
+func (publicKey *PublicKey) NewRandomnessPool(ctx context.Context, size int, optionalConcurrency ...int) (*RandomnessPool, error) {
+	var concurrency int
+	if 0 < len(optionalConcurrency) {
+		if 1 < len(optionalConcurrency) {
+			panic(errors.New("NewRandomnessPool: expected 0 or 1 item in `optionalConcurrency`"))
+		}
+		concurrency = optionalConcurrency[0]
+	} else {
+		concurrency = runtime.NumCPU()
+	}
+	if size < 1 || concurrency < 1 {
+		return nil, errors.New("NewRandomnessPool: size and concurrency must be positive")
+	}
+	if publicKey == nil || publicKey.N == nil || publicKey.N.Sign() != 1 {
+		return nil, errors.New("NewRandomnessPool: invalid public key")
+	}
+	pool := &RandomnessPool{publicKey: publicKey, pairs: make(chan *RandomnessPair, size)}
+	pool.wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go pool.fill(ctx)
+	}
+	return pool, nil
+}
+
+func (pool *RandomnessPool) fill(ctx context.Context) {
+	defer pool.wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		pair := pool.publicKey.newRandomnessPair()
+		select {
+		case pool.pairs <- pair:
+			atomic.AddUint64(&pool.generated, 1)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Wait blocks until all of the workers have returned after the context is done.
+func (pool *RandomnessPool) Wait() {
+	pool.wg.Wait()
+}
+
+// Next removes a pair from the pool, computing a fresh one inline if the pool is empty.
+func (pool *RandomnessPool) Next() *RandomnessPair {
+	select {
+	case pair := <-pool.pairs:
+		atomic.AddUint64(&pool.consumed, 1)
+		return pair
+	default:
+		atomic.AddUint64(&pool.misses, 1)
+		return pool.publicKey.newRandomnessPair()
+	}
+}
+
+// Stats returns the pool's capacity (Size), the pairs ready to be handed out (Available), the pairs the workers
+// have computed so far (Generated), the pairs Next took from the pool (Consumed) and the pairs Next had to compute
+// inline because the pool was empty (Misses).
+func (pool *RandomnessPool) Stats() RandomnessPoolStats {
+	return RandomnessPoolStats{
+		Size:      cap(pool.pairs),
+		Available: len(pool.pairs),
+		Generated: atomic.LoadUint64(&pool.generated),
+		Consumed:  atomic.LoadUint64(&pool.consumed),
+		Misses:    atomic.LoadUint64(&pool.misses),
+	}
+}
+
+// EncryptAndReturnRandomness is PublicKey.EncryptAndReturnRandomness using a pair from the pool
+func (pool *RandomnessPool) EncryptAndReturnRandomness(m *big.Int) (c *big.Int, x *big.Int, err error) {
+	pk := pool.publicKey
+	if m.Cmp(zero) == -1 || m.Cmp(pk.N) != -1 { // m < 0 || m >= N ?
+		return nil, nil, ErrMessageTooLong
+	}
+	pair := pool.Next()
+	N2 := pk.NSquare()
+	// 1. gamma^m = 1 + m*N mod N2
+	Gm := new(big.Int).Mul(m, pk.N)
+	Gm = Gm.Add(Gm, one)
+	// 2. (1) * x^N mod N2
+	c = prime.ModInt(N2).Mul(Gm, pair.RN)
+	return c, pair.R, nil
+}
+
+// Encrypt is PublicKey.Encrypt using a pair from the pool
+func (pool *RandomnessPool) Encrypt(m *big.Int) (c *big.Int, err error) {
+	c, _, err = pool.EncryptAndReturnRandomness(m)
+	return
+}
+
+// ----- utils
+
+func (publicKey *PublicKey) newRandomnessPair() *RandomnessPair {
+	r := curve.GetRandomPositiveRelativelyPrimeInt(publicKey.N)
+	rN := new(big.Int).Exp(r, publicKey.N, publicKey.NSquare())
+	return &RandomnessPair{R: r, RN: rN}
+}
@@ -0,0 +1,128 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package paillier
+
+import (
+	"github.com/zhp12543/zk-proof/prime"
+	"math/big"
+)
+
+// Signed plaintexts live in (-N/2, N/2] and are encoded as their residue mod N,
+// so that the negative half maps onto (N/2, N).
+
+// EncodeSigned maps m in (-N/2, N/2] to the plaintext m mod N
+func (publicKey *PublicKey) EncodeSigned(m *big.Int) (*big.Int, error) {
+	if m == nil || !publicKey.isInSignedRange(m) {
+		return nil, ErrMessageTooLong
+	}
+	return new(big.Int).Mod(m, publicKey.N), nil
+}
+
+// DecodeSigned maps a plaintext in [0, N) back to (-N/2, N/2]
+func (publicKey *PublicKey) DecodeSigned(m *big.Int) (*big.Int, error) {
+	if m == nil || m.Cmp(zero) == -1 || m.Cmp(publicKey.N) != -1 { // m < 0 || m >= N ?
+		return nil, ErrMessageTooLong
+	}
+	if m.Cmp(publicKey.halfN()) == 1 {
+		return new(big.Int).Sub(m, publicKey.N), nil
+	}
+	return new(big.Int).Set(m), nil
+}
+
+func (publicKey *PublicKey) EncryptSignedAndReturnRandomness(m *big.Int) (c *big.Int, x *big.Int, err error) {
+	encoded, err := publicKey.EncodeSigned(m)
+	if err != nil {
+		return nil, nil, err
+	}
+	return publicKey.EncryptAndReturnRandomness(encoded)
+}
+
+func (publicKey *PublicKey) EncryptSigned(m *big.Int) (c *big.Int, err error) {
+	c, _, err = publicKey.EncryptSignedAndReturnRandomness(m)
+	return
+}
+
+// HomoNeg returns c^-1 mod N2, an encryption of -m
+func (publicKey *PublicKey) HomoNeg(c *big.Int) (*big.Int, error) {
+	N2 := publicKey.NSquare()
+	if c.Cmp(zero) == -1 || c.Cmp(N2) != -1 { // c < 0 || c >= N2 ?
+		return nil, ErrMessageTooLong
+	}
+	inv := new(big.Int).ModInverse(c, N2)
+	if inv == nil {
+		return nil, ErrMessageMalFormed
+	}
+	return inv, nil
+}
+
+// HomoSub returns c1 * c2^-1 mod N2, an encryption of m1 - m2
+func (publicKey *PublicKey) HomoSub(c1, c2 *big.Int) (*big.Int, error) {
+	negC2, err := publicKey.HomoNeg(c2)
+	if err != nil {
+		return nil, err
+	}
+	return publicKey.HomoAdd(c1, negC2)
+}
+
+// HomoMultSigned is HomoMult for a scalar m in (-N/2, N/2]
+func (publicKey *PublicKey) HomoMultSigned(m, c1 *big.Int) (*big.Int, error) {
+	if m == nil || !publicKey.isInSignedRange(m) {
+		return nil, ErrMessageTooLong
+	}
+	if m.Sign() != -1 {
+		return publicKey.HomoMult(m, c1)
+	}
+	negC1, err := publicKey.HomoNeg(c1)
+	if err != nil {
+		return nil, err
+	}
+	return publicKey.HomoMult(new(big.Int).Neg(m), negC1)
+}
+
+// HomoAddPlaintext returns c * gamma^m mod N2, an encryption of the plaintext sum without fresh randomness
+func (publicKey *PublicKey) HomoAddPlaintext(c, m *big.Int) (*big.Int, error) {
+	if m.Cmp(zero) == -1 || m.Cmp(publicKey.N) != -1 { // m < 0 || m >= N ?
+		return nil, ErrMessageTooLong
+	}
+	N2 := publicKey.NSquare()
+	if c.Cmp(zero) == -1 || c.Cmp(N2) != -1 { // c < 0 || c >= N2 ?
+		return nil, ErrMessageTooLong
+	}
+	// gamma^m = 1 + m*N mod N2
+	Gm := new(big.Int).Mul(m, publicKey.N)
+	Gm = Gm.Add(Gm, one)
+	return prime.ModInt(N2).Mul(c, Gm), nil
+}
+
+// HomoAddPlaintextSigned is HomoAddPlaintext for m in (-N/2, N/2]
+func (publicKey *PublicKey) HomoAddPlaintextSigned(c, m *big.Int) (*big.Int, error) {
+	encoded, err := publicKey.EncodeSigned(m)
+	if err != nil {
+		return nil, err
+	}
+	return publicKey.HomoAddPlaintext(c, encoded)
+}
+
+// ----- //
+
+func (privateKey *PrivateKey) DecryptSigned(c *big.Int) (m *big.Int, err error) {
+	if m, err = privateKey.DecryptCRT(c); err != nil {
+		return nil, err
+	}
+	return privateKey.DecodeSigned(m)
+}
+
+// ----- utils
+
+func (publicKey *PublicKey) halfN() *big.Int {
+	return new(big.Int).Rsh(publicKey.N, 1)
+}
+
+// isInSignedRange checks -N/2 < m <= N/2; N is odd so this is |m| <= floor(N/2)
+func (publicKey *PublicKey) isInSignedRange(m *big.Int) bool {
+	return new(big.Int).Abs(m).Cmp(publicKey.halfN()) != 1
+}
@@ -0,0 +1,258 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+// Threshold decryption splits a PrivateKey generated from safe primes among n parties so that any t of them
+// can jointly decrypt, while fewer learn nothing about the plaintext.
+//
+// Implementation adheres to Damgård, I., Jurik, M.: A Generalisation, a Simplification and Some Applications of
+// Paillier's Probabilistic Public-Key System. In: PKC 2001, Section 4.2 (with s = 1), which follows Shoup's
+// threshold RSA: the dealer shares d = 0 mod p'q', d = 1 mod N with a polynomial of degree t-1 over Z_(N*p'q').
+
+package paillier
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"github.com/zhp12543/zk-proof/cmt"
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/prime"
+	"math/big"
+)
+
+const (
+	// statistical hiding of the witness in the decryption share proof, in bits
+	thresholdProofSlack = 128
+)
+
+type (
+	ThresholdPublicKey struct {
+		PublicKey
+		Threshold, // t, the number of shares needed to decrypt
+		PartyCount int // n
+		V  *big.Int   // generator of the squares in Z*_N2
+		Vs []*big.Int // verification keys V^(Delta*s_i), Vs[i-1] belongs to party i
+	}
+
+	ThresholdKeyShare struct {
+		*ThresholdPublicKey
+		Index int // 1..n
+		Share *big.Int
+	}
+
+	// DecryptionShareProof proves log_(c^4)(Ci^2) = log_V(V_i) (Fig. 4.2 of the paper)
+	DecryptionShareProof struct {
+		A, B, Z *big.Int
+	}
+
+	DecryptionShare struct {
+		Index int
+		Ci    *big.Int // c^(2*Delta*s_i) mod N2
+		Proof *DecryptionShareProof
+	}
+)
+
+// DealThreshold acts as a trusted dealer and splits the key into `partyCount` shares, any `threshold` of which can decrypt.
+// The key must have been generated from safe primes P = 2p'+1, Q = 2q'+1 as GenerateKeyPair does.
+func (privateKey *PrivateKey) DealThreshold(threshold, partyCount int) (*ThresholdPublicKey, []*ThresholdKeyShare, error) {
+	if threshold < 1 || partyCount < threshold {
+		return nil, nil, fmt.Errorf("DealThreshold: invalid threshold %d for %d parties", threshold, partyCount)
+	}
+	if privateKey.P == nil || privateKey.Q == nil {
+		return nil, nil, errors.New("DealThreshold: the key does not carry P and Q")
+	}
+	N, N2 := privateKey.N, privateKey.NSquare()
+	pPrm := new(big.Int).Rsh(privateKey.P, 1)
+	qPrm := new(big.Int).Rsh(privateKey.Q, 1)
+	if !pPrm.ProbablyPrime(30) || !qPrm.ProbablyPrime(30) {
+		return nil, nil, errors.New("DealThreshold: P and Q must be safe primes")
+	}
+	mPrm := new(big.Int).Mul(pPrm, qPrm)
+	NmPrm := new(big.Int).Mul(N, mPrm)
+
+	// 1. d = 0 mod p'q', d = 1 mod N, i.e. d = p'q' * (p'q'^-1 mod N)
+	mPrmInv := new(big.Int).ModInverse(mPrm, N)
+	if mPrmInv == nil {
+		return nil, nil, errors.New("DealThreshold: p'q' is not invertible mod N")
+	}
+	d := new(big.Int).Mul(mPrm, mPrmInv)
+
+	// 2. f(X) = d + a_1 X + ... + a_(t-1) X^(t-1) mod N*p'q'
+	coeffs := make([]*big.Int, threshold)
+	coeffs[0] = d
+	for i := 1; i < threshold; i++ {
+		coeffs[i] = curve.GetRandomPositiveInt(NmPrm)
+	}
+
+	// 3. V generates the squares; V_i = V^(Delta*s_i) mod N2
+	delta := factorial(partyCount)
+	r := curve.GetRandomPositiveRelativelyPrimeInt(N2)
+	modN2 := prime.ModInt(N2)
+	V := modN2.Mul(r, r)
+
+	tpk := &ThresholdPublicKey{
+		PublicKey:  PublicKey{N: N},
+		Threshold:  threshold,
+		PartyCount: partyCount,
+		V:          V,
+		Vs:         make([]*big.Int, partyCount),
+	}
+	shares := make([]*ThresholdKeyShare, partyCount)
+	modNmPrm := prime.ModInt(NmPrm)
+	for i := 1; i <= partyCount; i++ {
+		x := big.NewInt(int64(i))
+		si := new(big.Int).Set(coeffs[threshold-1])
+		for j := threshold - 2; j >= 0; j-- {
+			si = modNmPrm.Add(modNmPrm.Mul(si, x), coeffs[j])
+		}
+		tpk.Vs[i-1] = modN2.Exp(V, new(big.Int).Mul(delta, si))
+		shares[i-1] = &ThresholdKeyShare{ThresholdPublicKey: tpk, Index: i, Share: si}
+	}
+	return tpk, shares, nil
+}
+
+// PartialDecrypt computes this party's decryption share of c along with a proof of its correctness.
+// With a session the proof is bound to the session ID and the share index, which stands in for the session's Party.
+func (share *ThresholdKeyShare) PartialDecrypt(c *big.Int, optionalSession ...*cmt.Session) (*DecryptionShare, error) {
+	if err := share.ValidateCiphertext(c); err != nil {
+		return nil, err
+	}
+	N2 := share.NSquare()
+	modN2 := prime.ModInt(N2)
+	// x = Delta * s_i
+	x := new(big.Int).Mul(factorial(share.PartyCount), share.Share)
+	// c_i = c^(2x)
+	ci := modN2.Exp(c, new(big.Int).Lsh(x, 1))
+
+	// proof that log_(c^4)(c_i^2) = log_V(V_i) = x
+	c4 := modN2.Exp(c, big.NewInt(4))
+	ci2 := modN2.Mul(ci, ci)
+	vi := share.Vs[share.Index-1]
+	rBound := new(big.Int).Lsh(one, uint(N2.BitLen()+x.BitLen()+cmt.HashLength+thresholdProofSlack))
+	r, err := rand.Int(rand.Reader, rBound)
+	if err != nil {
+		return nil, err
+	}
+	a := modN2.Exp(c4, r)
+	b := modN2.Exp(share.V, r)
+	e := decShareChallenge(share.N, c4, ci2, share.V, vi, a, b, share.Index, cmt.OptionalSession(optionalSession))
+	z := new(big.Int).Mul(e, x)
+	z = z.Add(z, r)
+	return &DecryptionShare{Index: share.Index, Ci: ci, Proof: &DecryptionShareProof{A: a, B: b, Z: z}}, nil
+}
+
+// VerifyDecryptionShare checks the proof attached to a decryption share of c
+func (tpk *ThresholdPublicKey) VerifyDecryptionShare(c *big.Int, ds *DecryptionShare, optionalSession ...*cmt.Session) bool {
+	if ds == nil || ds.Ci == nil || ds.Proof == nil || !ds.Proof.ValidateBasic() {
+		return false
+	}
+	if ds.Index < 1 || tpk.PartyCount < ds.Index || len(tpk.Vs) != tpk.PartyCount {
+		return false
+	}
+	if tpk.ValidateCiphertext(c) != nil || tpk.ValidateCiphertext(ds.Ci) != nil {
+		return false
+	}
+	N2 := tpk.NSquare()
+	if !prime.IsInInterval(ds.Proof.A, N2) || !prime.IsInInterval(ds.Proof.B, N2) || ds.Proof.Z.Sign() == -1 {
+		return false
+	}
+	modN2 := prime.ModInt(N2)
+	c4 := modN2.Exp(c, big.NewInt(4))
+	ci2 := modN2.Mul(ds.Ci, ds.Ci)
+	vi := tpk.Vs[ds.Index-1]
+	e := decShareChallenge(tpk.N, c4, ci2, tpk.V, vi, ds.Proof.A, ds.Proof.B, ds.Index, cmt.OptionalSession(optionalSession))
+
+	// (c^4)^z = a * (c_i^2)^e
+	left := modN2.Exp(c4, ds.Proof.Z)
+	right := modN2.Mul(ds.Proof.A, modN2.Exp(ci2, e))
+	if left.Cmp(right) != 0 {
+		return false
+	}
+	// V^z = b * V_i^e
+	left = modN2.Exp(tpk.V, ds.Proof.Z)
+	right = modN2.Mul(ds.Proof.B, modN2.Exp(vi, e))
+	return left.Cmp(right) == 0
+}
+
+// Combine recovers the plaintext from the first `Threshold` valid decryption shares of c.
+// Shares that fail VerifyDecryptionShare or repeat an index are skipped, so it only fails when too few valid shares remain.
+func (tpk *ThresholdPublicKey) Combine(c *big.Int, shares []*DecryptionShare, optionalSession ...*cmt.Session) (*big.Int, error) {
+	if len(shares) < tpk.Threshold {
+		return nil, fmt.Errorf("Combine: expected at least %d decryption shares but got %d", tpk.Threshold, len(shares))
+	}
+	valid := make([]*DecryptionShare, 0, tpk.Threshold)
+	seen := make(map[int]bool, tpk.Threshold)
+	for _, ds := range shares {
+		if len(valid) == tpk.Threshold {
+			break
+		}
+		if ds == nil || seen[ds.Index] || !tpk.VerifyDecryptionShare(c, ds, optionalSession...) {
+			continue
+		}
+		seen[ds.Index] = true
+		valid = append(valid, ds)
+	}
+	if len(valid) < tpk.Threshold {
+		return nil, fmt.Errorf("Combine: expected at least %d valid decryption shares but got %d", tpk.Threshold, len(valid))
+	}
+	shares = valid
+	N, N2 := tpk.N, tpk.NSquare()
+	modN2 := prime.ModInt(N2)
+	delta := factorial(tpk.PartyCount)
+
+	// c' = prod c_i^(2 * lambda_(0,i)) = (1+N)^(4 * Delta^2 * m) mod N2
+	cPrm := big.NewInt(1)
+	for _, ds := range shares {
+		lambda := lagrangeCoefficient(delta, ds.Index, shares)
+		cPrm = modN2.Mul(cPrm, modN2.Exp(ds.Ci, new(big.Int).Lsh(lambda, 1)))
+	}
+	// m = L(c') * (4 * Delta^2)^-1 mod N
+	fourDelta2 := new(big.Int).Mul(delta, delta)
+	fourDelta2 = fourDelta2.Lsh(fourDelta2, 2)
+	inv := new(big.Int).ModInverse(fourDelta2, N)
+	if inv == nil {
+		return nil, ErrMessageMalFormed
+	}
+	return prime.ModInt(N).Mul(L(cPrm, N), inv), nil
+}
+
+func (pf *DecryptionShareProof) ValidateBasic() bool {
+	return pf.A != nil && pf.B != nil && pf.Z != nil
+}
+
+// ----- utils
+
+func factorial(n int) *big.Int {
+	return new(big.Int).MulRange(1, int64(n))
+}
+
+// lagrangeCoefficient returns the integer Delta * prod_(j != i) (-j) / (i - j) over the indices in `shares`
+func lagrangeCoefficient(delta *big.Int, i int, shares []*DecryptionShare) *big.Int {
+	num, den := new(big.Int).Set(delta), big.NewInt(1)
+	for _, ds := range shares {
+		j := ds.Index
+		if j == i {
+			continue
+		}
+		num = num.Mul(num, big.NewInt(int64(-j)))
+		den = den.Mul(den, big.NewInt(int64(i-j)))
+	}
+	return num.Quo(num, den)
+}
+
+// decShareChallenge derives e for the decryption share of the key share at `index`, which is also the party bound
+// to the session
+func decShareChallenge(N, c4, ci2, V, vi, a, b *big.Int, index int, session *cmt.Session) *big.Int {
+	if session != nil {
+		session = &cmt.Session{ID: session.ID, Party: big.NewInt(int64(index))}
+	}
+	ch := &cmt.Challenge{
+		Domain:     "zk-proof/paillier/threshold/v1",
+		Statement:  []*big.Int{N, c4, ci2, V, vi},
+		Commitment: []*big.Int{a, b},
+	}
+	return ch.Bits(session, cmt.HashLength)
+}
@@ -54,7 +54,11 @@ func GeneratePreParamsWithContext(ctx context.Context, optionalConcurrency ...in
 	if concurrency /= 3; concurrency < 1 {
 		concurrency = 1
 	}
+	return generatePreParams(ctx, paillierModulusLen, safePrimeBitLen, concurrency)
+}
 
+// generatePreParams is GeneratePreParamsWithContext with explicit sizes; tests use it with smaller moduli.
+func generatePreParams(ctx context.Context, paillierBitLen, safePrimeBitLen, concurrency int) (*PaillierParams, error) {
 	// prepare for concurrent Paillier and safe prime generation
 	paiCh := make(chan *paillier.PrivateKey, 1)
 	sgpCh := make(chan []*prime.GermainSafePrime, 1)
@@ -64,7 +68,7 @@ func GeneratePreParamsWithContext(ctx context.Context, optionalConcurrency ...in
 		fmt.Println("generating the Paillier modulus, please wait...")
 		start := time.Now()
 		// more concurrency weight is assigned here because the paillier primes have a requirement of having "large" P-Q
-		PiPaillierSk, _, err := paillier.GenerateKeyPair(ctx, paillierModulusLen, concurrency*2)
+		PiPaillierSk, _, err := paillier.GenerateKeyPair(ctx, paillierBitLen, concurrency*2)
 		if err != nil {
 			ch <- nil
 			return
@@ -72,7 +72,9 @@ func TestProof(t *testing.T)  {
 		param1.H2i,
 		param3.NTildei,
 		param3.H1i,
-		param3.H2i)
+		param3.H2i,
+		nil,
+		nil)
 
 	fmt.Println("BobMid err:", err)
 	_, err = mta.AliceEnd(
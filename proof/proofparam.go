@@ -1,8 +1,12 @@
 package proof
 
 import (
+	"crypto/elliptic"
 	"errors"
+	"fmt"
+	"github.com/zhp12543/zk-proof/cmt"
 	"github.com/zhp12543/zk-proof/dln"
+	"github.com/zhp12543/zk-proof/facproof"
 	"github.com/zhp12543/zk-proof/paillier"
 	"math/big"
 	"sync"
@@ -39,7 +43,8 @@ func UnFlatPaillierPublic(in []*big.Int) (*PaillierParams, error) {
 	return p, nil
 }
 
-func (pk *PaillierParams) VerifyDln( dln1 [][]byte, dln2 [][]byte) error {
+// VerifyDln checks the DLN proofs of H1 and H2 made by DlnProof, with the same optional session
+func (pk *PaillierParams) VerifyDln( dln1 [][]byte, dln2 [][]byte, optionalSession ...*cmt.Session) error {
 	if pk.H1i.Cmp(pk.H2i) == 0 || pk.NTildei.BitLen() != paillierModulusLen ||
 		pk.PaillierSK.N.BitLen() != paillierModulusLen {
 		return errors.New("got paillier modulus with insufficient bits for this party")
@@ -58,8 +63,8 @@ func (pk *PaillierParams) VerifyDln( dln1 [][]byte, dln2 [][]byte) error {
 			return
 		}
 
-		if !dlnProof1.Verify(pk.H1i, pk.H2i, pk.NTildei) {
-			errChain <- errors.New("dln1 verify false")
+		if err = dlnProof1.VerifyWithReason(pk.H1i, pk.H2i, pk.NTildei, optionalSession...); err != nil {
+			errChain <- fmt.Errorf("dln1 verify false: %w", err)
 			return
 		}
 	}()
@@ -72,8 +77,8 @@ func (pk *PaillierParams) VerifyDln( dln1 [][]byte, dln2 [][]byte) error {
 			return
 		}
 
-		if !dlnProof2.Verify(pk.H2i, pk.H1i, pk.NTildei) {
-			errChain <- errors.New("dln2 verify false")
+		if err = dlnProof2.VerifyWithReason(pk.H2i, pk.H1i, pk.NTildei, optionalSession...); err != nil {
+			errChain <- fmt.Errorf("dln2 verify false: %w", err)
 			return
 		}
 	}()
@@ -91,14 +96,16 @@ func (pk *PaillierParams) VerifyDln( dln1 [][]byte, dln2 [][]byte) error {
 	}
 }
 
-func (pk *PaillierParams) DlnProof() ([][]byte, [][]byte, error) {
+// DlnProof proves that H1 and H2 generate the same group mod NTilde.
+func (pk *PaillierParams) DlnProof(optionalSession ...*cmt.Session) ([][]byte, [][]byte, error) {
 	dln1, err := dln.NewDLNProof(
 		pk.H1i,
 		pk.H2i,
 		pk.Alpha,
 		pk.P,
 		pk.Q,
-		pk.NTildei).Serialize()
+		pk.NTildei,
+		optionalSession...).Serialize()
 
 	if err != nil {
 		return nil, nil, err
@@ -110,10 +117,80 @@ func (pk *PaillierParams) DlnProof() ([][]byte, [][]byte, error) {
 		pk.Beta,
 		pk.P,
 		pk.Q,
-		pk.NTildei).Serialize()
+		pk.NTildei,
+		optionalSession...).Serialize()
 
 	if err != nil {
 		return nil, nil, err
 	}
 	return dln1, dln2, nil
-}
\ No newline at end of file
+}
+
+// FacProof proves that our Paillier modulus has no small factors, using the verifier's NTilde/H1/H2 as the ring-Pedersen parameters.
+func (pk *PaillierParams) FacProof(ec elliptic.Curve, verifier *PaillierParams, optionalSession ...*cmt.Session) ([][]byte, error) {
+	if pk.PaillierSK == nil || verifier == nil {
+		return nil, errors.New("FacProof received nil params")
+	}
+	pf, err := facproof.NewProof(
+		ec,
+		pk.PaillierSK.N,
+		verifier.NTildei,
+		verifier.H1i,
+		verifier.H2i,
+		pk.PaillierSK.P,
+		pk.PaillierSK.Q,
+		optionalSession...)
+	if err != nil {
+		return nil, err
+	}
+	bzs := pf.Bytes()
+	return bzs[:], nil
+}
+
+// VerifyFac checks a FacProof of pk's Paillier modulus that was made against our (the verifier's) NTilde/H1/H2.
+func (pk *PaillierParams) VerifyFac(ec elliptic.Curve, verifier *PaillierParams, fac [][]byte, optionalSession ...*cmt.Session) error {
+	if pk.PaillierSK == nil || verifier == nil {
+		return errors.New("VerifyFac received nil params")
+	}
+	pf, err := facproof.NewProofFromBytes(fac)
+	if err != nil {
+		return err
+	}
+	if err = pf.VerifyWithReason(ec, pk.PaillierSK.N, verifier.NTildei, verifier.H1i, verifier.H2i, optionalSession...); err != nil {
+		return fmt.Errorf("fac verify false: %w", err)
+	}
+	return nil
+}
+
+// PrmProof is a compact alternative to DlnProof: a single Πprm proof that H2 = H1^Alpha mod NTilde.
+// iterations is usually dln.PrmIterations.
+func (pk *PaillierParams) PrmProof(iterations int, optionalSession ...*cmt.Session) ([][]byte, error) {
+	pf, err := dln.NewPrmProof(
+		pk.H2i,
+		pk.H1i,
+		pk.Alpha,
+		pk.P,
+		pk.Q,
+		pk.NTildei,
+		iterations,
+		optionalSession...)
+	if err != nil {
+		return nil, err
+	}
+	return pf.Serialize()
+}
+
+// VerifyPrm checks a PrmProof made with the same number of iterations.
+func (pk *PaillierParams) VerifyPrm(prm [][]byte, iterations int, optionalSession ...*cmt.Session) error {
+	if pk.H1i.Cmp(pk.H2i) == 0 || pk.NTildei.BitLen() != paillierModulusLen {
+		return errors.New("got NTilde with insufficient bits for this party")
+	}
+	pf, err := dln.UnmarshalPrmProof(prm)
+	if err != nil {
+		return err
+	}
+	if err = pf.VerifyWithReason(pk.H2i, pk.H1i, pk.NTildei, iterations, optionalSession...); err != nil {
+		return fmt.Errorf("prm verify false: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package proof
+
+import (
+	"context"
+	"crypto/elliptic"
+	"errors"
+	"github.com/zhp12543/zk-proof/abort"
+	"github.com/zhp12543/zk-proof/facproof"
+	"math/big"
+	"testing"
+	"time"
+)
+
+const (
+	// smaller than the production sizes to keep the tests fast; the proofs do not depend on them
+	testPaillierModulusLen = 1024
+	testSafePrimeBitLen    = 512
+)
+
+var testParams []*PaillierParams
+
+// loadTestParams generates two parties' pre-params once for all tests in this file
+func loadTestParams(t *testing.T) (*PaillierParams, *PaillierParams) {
+	for len(testParams) < 2 {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		params, err := generatePreParams(ctx, testPaillierModulusLen, testSafePrimeBitLen, 1)
+		cancel()
+		if err != nil {
+			t.Fatal(err)
+		}
+		testParams = append(testParams, params)
+	}
+	return testParams[0], testParams[1]
+}
+
+func TestFacProof(t *testing.T) {
+	ec := elliptic.P256()
+	prover, verifier := loadTestParams(t)
+	fac, err := prover.FacProof(ec, verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proverPub, err := UnFlatPaillierPublic(prover.FlatPaillierPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := proverPub.VerifyFac(ec, verifier, fac); err != nil {
+		t.Fatal(err)
+	}
+	// the proof is bound to the verifier's ring-Pedersen parameters
+	if err := proverPub.VerifyFac(ec, prover, fac); err == nil {
+		t.Error("FacProof verified against the wrong NTilde")
+	}
+	if err := verifier.VerifyFac(ec, verifier, fac); err == nil {
+		t.Error("FacProof verified for the wrong Paillier modulus")
+	}
+}
+
+func TestFacProofVerifyWithReason(t *testing.T) {
+	ec := elliptic.P256()
+	prover, verifier := loadTestParams(t)
+	fac, err := prover.FacProof(ec, verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pf, err := facproof.NewProofFromBytes(fac)
+	if err != nil {
+		t.Fatal(err)
+	}
+	N0 := prover.PaillierSK.N
+	verify := func(pf *facproof.ProofFac) error {
+		return pf.VerifyWithReason(ec, N0, verifier.NTildei, verifier.H1i, verifier.H2i)
+	}
+	if err = verify(pf); err != nil {
+		t.Fatalf("valid proof was rejected: %v", err)
+	}
+
+	add1 := func(x *big.Int) *big.Int { return new(big.Int).Add(x, big.NewInt(1)) }
+	tests := []struct {
+		name   string
+		tamper func(pf *facproof.ProofFac)
+		kind   abort.ReasonKind
+		step   string
+	}{
+		{"p not coprime", func(pf *facproof.ProofFac) { pf.P = big.NewInt(0) }, abort.Malformed, ""},
+		{"z1 too large", func(pf *facproof.ProofFac) { pf.Z1 = new(big.Int).Lsh(N0, 1) }, abort.Rejected, "range check"},
+		{"w1 changed", func(pf *facproof.ProofFac) { pf.W1 = add1(pf.W1) }, abort.Rejected, "equality check 1"},
+		{"w2 changed", func(pf *facproof.ProofFac) { pf.W2 = add1(pf.W2) }, abort.Rejected, "equality check 2"},
+		{"v changed", func(pf *facproof.ProofFac) { pf.V = add1(pf.V) }, abort.Rejected, "equality check 3"},
+	}
+	for _, tt := range tests {
+		bad := *pf
+		tt.tamper(&bad)
+		err := verify(&bad)
+		var ve *abort.VerifyError
+		if !errors.As(err, &ve) || ve.Kind != tt.kind || ve.Step != tt.step {
+			t.Errorf("%s: unexpected reason %v", tt.name, err)
+		}
+	}
+	// VerifyFac keeps the reason
+	bzs := pf.Bytes()
+	bzs[9] = add1(pf.W2).Bytes()
+	if err := prover.VerifyFac(ec, verifier, bzs[:]); !abort.IsRejected(err) {
+		t.Errorf("expected VerifyFac to wrap the rejection, got %v", err)
+	}
+}
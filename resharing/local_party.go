@@ -0,0 +1,245 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+// Package resharing moves a t-of-n ECDSA key produced by the keygen package to a new t'-of-n' committee without
+// changing the public key (Wong, T. M., Wang, C., Wing, J. M.: Verifiable Secret Redistribution for Archive Systems).
+//
+// Each of at least t+1 old parties turns its share into an additive share w_i = lambda_i * x_i of the secret and
+// deals it to the new committee with Feldman VSS. The new parties sum what they receive into fresh Shamir shares,
+// and exchange newly generated Paillier and ring-Pedersen parameters with their DLN, modulus and no small factor
+// proofs, so the output can be used for signing like a freshly generated key.
+//
+// Usage: every old party calls SendShares once and delivers its messages, after which it must discard its old share.
+// Every new party calls Round1 and delivers its messages, then passes every message it receives to Update and
+// delivers whatever it returns, until Outputs reports that the new key share is ready.
+// A party in both committees plays both roles separately.
+
+package resharing
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"github.com/zhp12543/zk-proof/cmt"
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/keygen"
+	"github.com/zhp12543/zk-proof/paillier"
+	"github.com/zhp12543/zk-proof/proof"
+	"github.com/zhp12543/zk-proof/vss"
+	"math/big"
+)
+
+type (
+	Parameters struct {
+		EC           elliptic.Curve
+		OldPartyIDs  []*big.Int       // the Shamir indexes of the old parties taking part, at least OldThreshold+1 of them
+		OldBigXs     []*curve.ECPoint // the old parties' public shares X_j from the old key's BigXj, in the order of OldPartyIDs
+		OldThreshold int
+		NewPartyIDs  []*big.Int // the Shamir indexes of the new committee, in the same order for all parties
+		NewThreshold int
+		SessionID    []byte // unique per resharing and agreed on by both committees; binds every proof to this resharing
+	}
+
+	// LocalParty is the state machine of one party of the new committee
+	LocalParty struct {
+		params    *Parameters
+		index     int // our position in NewPartyIDs
+		preParams *proof.PaillierParams
+		round     int // the last round started, 0 before Round1
+		temp      localTempData
+		data      keygen.LocalPartySaveData
+		done      bool
+	}
+
+	localTempData struct {
+		// messages received from the old committee, indexed by sender
+		oldMsgs1 []*DGRound1OldMessage1
+		oldMsgs2 []*DGRound1OldMessage2
+		// messages received from the other new parties, indexed by sender
+		r1msgs []*DGRound1NewMessage
+		r2msgs []*DGRound2NewMessage
+	}
+)
+
+func NewParameters(ec elliptic.Curve, oldPartyIDs []*big.Int, oldBigXs []*curve.ECPoint, oldThreshold int, newPartyIDs []*big.Int, newThreshold int, sessionID []byte) (*Parameters, error) {
+	if ec == nil {
+		return nil, errors.New("resharing: nil curve")
+	}
+	if oldThreshold < 1 || len(oldPartyIDs) <= oldThreshold {
+		return nil, fmt.Errorf("resharing: expected at least %d old parties but got %d", oldThreshold+1, len(oldPartyIDs))
+	}
+	if len(oldBigXs) != len(oldPartyIDs) {
+		return nil, fmt.Errorf("resharing: expected %d old public shares but got %d", len(oldPartyIDs), len(oldBigXs))
+	}
+	for j, Xj := range oldBigXs {
+		if !Xj.ValidateBasic() || Xj.IsIdentity() {
+			return nil, fmt.Errorf("resharing: invalid public share of old party %d", j)
+		}
+	}
+	if newThreshold < 1 || len(newPartyIDs) <= newThreshold {
+		return nil, fmt.Errorf("resharing: invalid new threshold %d for %d parties", newThreshold, len(newPartyIDs))
+	}
+	if len(sessionID) == 0 {
+		return nil, errors.New("resharing: empty session ID")
+	}
+	if err := vss.CheckIndexes(ec, oldPartyIDs); err != nil {
+		return nil, err
+	}
+	if err := vss.CheckIndexes(ec, newPartyIDs); err != nil {
+		return nil, err
+	}
+	return &Parameters{
+		EC:           ec,
+		OldPartyIDs:  oldPartyIDs,
+		OldBigXs:     oldBigXs,
+		OldThreshold: oldThreshold,
+		NewPartyIDs:  newPartyIDs,
+		NewThreshold: newThreshold,
+		SessionID:    sessionID,
+	}, nil
+}
+
+func (params *Parameters) OldPartyCount() int {
+	return len(params.OldPartyIDs)
+}
+
+func (params *Parameters) NewPartyCount() int {
+	return len(params.NewPartyIDs)
+}
+
+// session binds the proofs made by new party j to this resharing
+func (params *Parameters) session(j int) *cmt.Session {
+	return &cmt.Session{ID: params.SessionID, Party: params.NewPartyIDs[j]}
+}
+
+// NewLocalParty creates the state machine of the new committee party at position `index` of NewPartyIDs.
+// The pre-params must be generated out-of-band with proof.GeneratePreParams and must not be reused from the old key.
+func NewLocalParty(params *Parameters, index int, preParams *proof.PaillierParams) (*LocalParty, error) {
+	if params == nil || preParams == nil || preParams.PaillierSK == nil {
+		return nil, errors.New("resharing: NewLocalParty received nil params")
+	}
+	if preParams.NTildei == nil || preParams.H1i == nil || preParams.H2i == nil {
+		return nil, errors.New("resharing: NewLocalParty received pre-params without NTilde, h1 and h2")
+	}
+	if index < 0 || params.NewPartyCount() <= index {
+		return nil, fmt.Errorf("resharing: party index %d out of range", index)
+	}
+	n := params.NewPartyCount()
+	p := &LocalParty{params: params, index: index, preParams: preParams}
+	p.temp.oldMsgs1 = make([]*DGRound1OldMessage1, params.OldPartyCount())
+	p.temp.oldMsgs2 = make([]*DGRound1OldMessage2, params.OldPartyCount())
+	p.temp.r1msgs = make([]*DGRound1NewMessage, n)
+	p.temp.r2msgs = make([]*DGRound2NewMessage, n)
+	p.data = keygen.LocalPartySaveData{
+		Ks:          make([]*big.Int, n),
+		NTildej:     make([]*big.Int, n),
+		H1j:         make([]*big.Int, n),
+		H2j:         make([]*big.Int, n),
+		PaillierPKs: make([]*paillier.PublicKey, n),
+	}
+	return p, nil
+}
+
+// Update stores a message from an old party or another new party. Once every message of the current round has
+// arrived it runs the next round and returns that round's outgoing messages, which may be none.
+func (p *LocalParty) Update(msg *Message) ([]*Message, error) {
+	if err := p.storeMessage(msg); err != nil {
+		return nil, err
+	}
+	var out []*Message
+	for !p.done && p.canProceed() {
+		var msgs []*Message
+		var err error
+		switch p.round {
+		case 1:
+			msgs, err = p.Round2()
+		case 2:
+			err = p.finish()
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, msgs...)
+	}
+	return out, nil
+}
+
+// Outputs returns the new key share once resharing has finished
+func (p *LocalParty) Outputs() (*keygen.LocalPartySaveData, bool) {
+	if !p.done {
+		return nil, false
+	}
+	return &p.data, true
+}
+
+func (p *LocalParty) storeMessage(msg *Message) error {
+	if msg == nil || msg.Content == nil || !msg.Content.ValidateBasic() {
+		return errors.New("resharing: received a malformed message")
+	}
+	from, fromOld := msg.From, msg.Content.IsFromOldCommittee()
+	if fromOld && (from < 0 || p.params.OldPartyCount() <= from) {
+		return fmt.Errorf("resharing: received a message from invalid old party %d", from)
+	}
+	if !fromOld && (from < 0 || p.params.NewPartyCount() <= from || from == p.index) {
+		return fmt.Errorf("resharing: received a message from invalid new party %d", from)
+	}
+	if msg.Content.IsBroadcast() != (msg.To == Broadcast) || (msg.To != Broadcast && msg.To != p.index) {
+		return fmt.Errorf("resharing: received a misrouted message from party %d", from)
+	}
+	dup := fmt.Errorf("resharing: received a duplicate round %d message from party %d", msg.Content.RoundNumber(), from)
+	switch content := msg.Content.(type) {
+	case *DGRound1OldMessage1:
+		if p.temp.oldMsgs1[from] != nil {
+			return dup
+		}
+		p.temp.oldMsgs1[from] = content
+	case *DGRound1OldMessage2:
+		if p.temp.oldMsgs2[from] != nil {
+			return dup
+		}
+		p.temp.oldMsgs2[from] = content
+	case *DGRound1NewMessage:
+		if p.temp.r1msgs[from] != nil {
+			return dup
+		}
+		p.temp.r1msgs[from] = content
+	case *DGRound2NewMessage:
+		if p.temp.r2msgs[from] != nil {
+			return dup
+		}
+		p.temp.r2msgs[from] = content
+	default:
+		return fmt.Errorf("resharing: received an unknown message type %T from party %d", content, from)
+	}
+	return nil
+}
+
+// canProceed reports whether every message needed by the next round has arrived.
+// The old committee's messages are only needed by finish.
+func (p *LocalParty) canProceed() bool {
+	switch p.round {
+	case 1:
+		for j, msg := range p.temp.r1msgs {
+			if j != p.index && msg == nil {
+				return false
+			}
+		}
+		return true
+	case 2:
+		for j, msg := range p.temp.r2msgs {
+			if j != p.index && msg == nil {
+				return false
+			}
+		}
+		for j := range p.temp.oldMsgs1 {
+			if p.temp.oldMsgs1[j] == nil || p.temp.oldMsgs2[j] == nil {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package resharing
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/keygen"
+	"github.com/zhp12543/zk-proof/proof"
+	"github.com/zhp12543/zk-proof/test"
+	"github.com/zhp12543/zk-proof/vss"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+const (
+	testParties      = 3
+	testThreshold    = 1
+	testNewParties   = 3
+	testNewThreshold = 2
+)
+
+func TestE2E(t *testing.T) {
+	ec := elliptic.P256()
+	oldKeys, err := test.RunKeygen(ec, testParties, testThreshold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	preParams, err := test.LoadPreParamsN(testParties, testNewParties)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// reshare from old parties 1 and 3 to a new 3-of-3 committee with different indexes
+	oldSigners := []*keygen.LocalPartySaveData{oldKeys[0], oldKeys[2]}
+	oldIDs := []*big.Int{oldKeys[0].ShareID, oldKeys[2].ShareID}
+	oldBigXs := []*curve.ECPoint{oldKeys[0].BigXj[0], oldKeys[0].BigXj[2]}
+	newIDs := make([]*big.Int, testNewParties)
+	for i := range newIDs {
+		newIDs[i] = big.NewInt(int64(testParties + i + 1))
+	}
+	sessionID, err := test.NewSessionID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, err := NewParameters(ec, oldIDs, oldBigXs, testThreshold, newIDs, testNewThreshold, sessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parties, err := runResharing(params, oldSigners, preParams)
+	if err != nil {
+		t.Fatalf("resharing %v", err)
+	}
+
+	newKeys := make([]*keygen.LocalPartySaveData, testNewParties)
+	shares := make(vss.Shares, testNewParties)
+	for i, party := range parties {
+		data, ok := party.Outputs()
+		if !ok {
+			t.Fatalf("new party %d did not finish", i)
+		}
+		if !data.ECDSAPub.Equals(oldKeys[0].ECDSAPub) {
+			t.Fatalf("new party %d has a different public key", i)
+		}
+		if data.NTildej[i].Cmp(oldKeys[0].NTildej[0]) == 0 {
+			t.Fatalf("new party %d reused an old NTilde", i)
+		}
+		newKeys[i] = data
+		shares[i] = &vss.Share{Threshold: testNewThreshold, ID: data.ShareID, Share: data.Xi}
+	}
+	secret, err := shares.ReConstruct(ec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !curve.ScalarBaseMult(ec, secret).Equals(oldKeys[0].ECDSAPub) {
+		t.Fatal("the new shares do not reconstruct the old secret")
+	}
+
+	// the new committee can sign for the unchanged public key
+	hash := sha256.Sum256([]byte("hello, resharing"))
+	sigs, err := test.RunSigning(ec, newKeys, new(big.Int).SetBytes(hash[:]), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &ecdsa.PublicKey{Curve: ec, X: oldKeys[0].ECDSAPub.X(), Y: oldKeys[0].ECDSAPub.Y()}
+	if !ecdsa.Verify(pub, hash[:], sigs[0].R, sigs[0].S) {
+		t.Fatal("the signature of the new committee does not verify with crypto/ecdsa")
+	}
+}
+
+func TestBadDealer(t *testing.T) {
+	ec := elliptic.P256()
+	oldKeys, err := test.RunKeygen(ec, testParties, testThreshold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	preParams, err := test.LoadPreParamsN(testParties, testNewParties)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newIDs := make([]*big.Int, testNewParties)
+	for i := range newIDs {
+		newIDs[i] = big.NewInt(int64(testParties + i + 1))
+	}
+	sessionID, err := test.NewSessionID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, err := NewParameters(ec, oldKeys[0].Ks[:2], oldKeys[0].BigXj[:2], testThreshold, newIDs, testNewThreshold, sessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// old party 1 deals a different secret
+	bad := *oldKeys[1]
+	bad.Xi = new(big.Int).Add(bad.Xi, big.NewInt(1))
+	_, err = runResharing(params, []*keygen.LocalPartySaveData{oldKeys[0], &bad}, preParams)
+	if err == nil || !strings.Contains(err.Error(), "old party 1 ") {
+		t.Fatalf("expected old party 1 to be blamed but got %v", err)
+	}
+
+	noNTilde := *preParams[0]
+	noNTilde.NTildei = nil
+	if _, err := NewLocalParty(params, 0, &noNTilde); err == nil {
+		t.Error("NewLocalParty accepted pre-params without NTilde")
+	}
+}
+
+// ----- utils
+
+// runResharing runs the new committee of params to the end with the shares dealt by oldSigners
+func runResharing(params *Parameters, oldSigners []*keygen.LocalPartySaveData, preParams []*proof.PaillierParams) ([]*LocalParty, error) {
+	n := params.NewPartyCount()
+	parties := make([]*LocalParty, n)
+	var queue []*Message
+	for i := range parties {
+		var err error
+		if parties[i], err = NewLocalParty(params, i, preParams[i]); err != nil {
+			return nil, err
+		}
+		out, err := parties[i].Round1()
+		if err != nil {
+			return nil, err
+		}
+		queue = append(queue, out...)
+	}
+	for i, key := range oldSigners {
+		out, err := SendShares(params, i, key)
+		if err != nil {
+			return nil, err
+		}
+		queue = append(queue, out...)
+	}
+	err := test.Route(queue, func(msg *Message) []int {
+		from := msg.From
+		if msg.Content.IsFromOldCommittee() {
+			from = -1 // old committee indexes do not refer to new parties
+		}
+		return test.Recipients(n, from, msg.To)
+	}, func(j int, msg *Message) ([]*Message, error) {
+		return parties[j].Update(msg)
+	})
+	return parties, err
+}
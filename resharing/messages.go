@@ -0,0 +1,115 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package resharing
+
+import (
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/facproof"
+	"github.com/zhp12543/zk-proof/paillier"
+	"math/big"
+)
+
+const (
+	// Broadcast is the Message.To value of a message sent to every party of the new committee
+	Broadcast = -1
+)
+
+type (
+	// Message is routed by the caller to party `To` of the new committee, or to all of it if To is Broadcast.
+	// From is the sender's position in OldPartyIDs if the content is from the old committee, and in NewPartyIDs otherwise.
+	Message struct {
+		From, To int
+		Content  MessageContent
+	}
+
+	MessageContent interface {
+		RoundNumber() int
+		IsBroadcast() bool
+		IsFromOldCommittee() bool
+		ValidateBasic() bool
+	}
+
+	// DGRound1OldMessage1 is sent point-to-point and carries the recipient's share of the sender's w_i
+	DGRound1OldMessage1 struct {
+		Share *big.Int
+	}
+
+	// DGRound1OldMessage2 publishes the public key and the Feldman VSS commitments to the sender's polynomial
+	DGRound1OldMessage2 struct {
+		ECDSAPub []*big.Int // flattened
+		Vs       []*big.Int // flattened
+	}
+
+	// DGRound1NewMessage publishes the sender's new Paillier and ring-Pedersen parameters with their proofs
+	DGRound1NewMessage struct {
+		PaillierN,
+		NTilde,
+		H1, H2 *big.Int
+		Dln1, Dln2 [][]byte
+		ModProof   [][]byte
+	}
+
+	// DGRound2NewMessage is sent point-to-point and proves that the sender's Paillier modulus has no small factors,
+	// made against the recipient's NTilde
+	DGRound2NewMessage struct {
+		FacProof [][]byte
+	}
+)
+
+func (m *DGRound1OldMessage1) RoundNumber() int         { return 1 }
+func (m *DGRound1OldMessage1) IsBroadcast() bool        { return false }
+func (m *DGRound1OldMessage1) IsFromOldCommittee() bool { return true }
+
+func (m *DGRound1OldMessage1) ValidateBasic() bool {
+	return m != nil && m.Share != nil
+}
+
+func (m *DGRound1OldMessage2) RoundNumber() int         { return 1 }
+func (m *DGRound1OldMessage2) IsBroadcast() bool        { return true }
+func (m *DGRound1OldMessage2) IsFromOldCommittee() bool { return true }
+
+func (m *DGRound1OldMessage2) ValidateBasic() bool {
+	return m != nil &&
+		len(m.ECDSAPub) == 2 &&
+		len(m.Vs) != 0 &&
+		nonNilInts(m.ECDSAPub) &&
+		nonNilInts(m.Vs)
+}
+
+func (m *DGRound1NewMessage) RoundNumber() int         { return 1 }
+func (m *DGRound1NewMessage) IsBroadcast() bool        { return true }
+func (m *DGRound1NewMessage) IsFromOldCommittee() bool { return false }
+
+func (m *DGRound1NewMessage) ValidateBasic() bool {
+	return m != nil &&
+		m.PaillierN != nil &&
+		m.NTilde != nil &&
+		m.H1 != nil &&
+		m.H2 != nil &&
+		len(m.Dln1) != 0 &&
+		len(m.Dln2) != 0 &&
+		curve.NonEmptyMultiBytes(m.ModProof, paillier.ModProofBytesParts)
+}
+
+func (m *DGRound2NewMessage) RoundNumber() int         { return 2 }
+func (m *DGRound2NewMessage) IsBroadcast() bool        { return false }
+func (m *DGRound2NewMessage) IsFromOldCommittee() bool { return false }
+
+func (m *DGRound2NewMessage) ValidateBasic() bool {
+	return m != nil && curve.NonEmptyMultiBytes(m.FacProof, facproof.ProofFacBytesParts)
+}
+
+// ----- utils
+
+func nonNilInts(in []*big.Int) bool {
+	for _, v := range in {
+		if v == nil {
+			return false
+		}
+	}
+	return true
+}
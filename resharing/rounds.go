@@ -0,0 +1,249 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package resharing
+
+import (
+	"errors"
+	"fmt"
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/keygen"
+	"github.com/zhp12543/zk-proof/paillier"
+	"github.com/zhp12543/zk-proof/proof"
+	"github.com/zhp12543/zk-proof/vss"
+	"math/big"
+)
+
+// SendShares is the whole part of the old party at position `index` of OldPartyIDs: it converts x_i to the additive
+// share w_i = lambda_i * x_i and sends every new party its Feldman VSS share of w_i, broadcasting the commitments
+// and the public key to the new committee.
+func SendShares(params *Parameters, index int, key *keygen.LocalPartySaveData) ([]*Message, error) {
+	if params == nil || key == nil || key.Xi == nil || key.ShareID == nil || key.ECDSAPub == nil {
+		return nil, errors.New("resharing: SendShares received nil params")
+	}
+	if index < 0 || params.OldPartyCount() <= index {
+		return nil, fmt.Errorf("resharing: old party index %d out of range", index)
+	}
+	if key.Threshold != params.OldThreshold {
+		return nil, fmt.Errorf("resharing: the key was generated for threshold %d", key.Threshold)
+	}
+	if key.ShareID.Cmp(params.OldPartyIDs[index]) != 0 {
+		return nil, errors.New("resharing: the key does not belong to our old party ID")
+	}
+	ec := params.EC
+	q := ec.Params().N
+
+	// 1. w_i = lambda_i * x_i
+	wi := new(big.Int).Mul(vss.LagrangeCoefficient(ec, params.OldPartyIDs, index), key.Xi)
+	wi = wi.Mod(wi, q)
+
+	// 2. share w_i among the new committee
+	vs, shares, err := vss.Create(ec, params.NewThreshold, wi, params.NewPartyIDs)
+	if err != nil {
+		return nil, err
+	}
+	flatVs, err := vs.Flat()
+	if err != nil {
+		return nil, err
+	}
+	flatPub, err := curve.FlattenECPoints([]*curve.ECPoint{key.ECDSAPub})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*Message, 0, params.NewPartyCount()+1)
+	for j, share := range shares {
+		out = append(out, &Message{From: index, To: j, Content: &DGRound1OldMessage1{Share: share.Share}})
+	}
+	out = append(out, &Message{
+		From:    index,
+		To:      Broadcast,
+		Content: &DGRound1OldMessage2{ECDSAPub: flatPub, Vs: flatVs},
+	})
+	return out, nil
+}
+
+// Round1 broadcasts our new Paillier and ring-Pedersen parameters along with their DLN proofs and a Paillier-Blum
+// modulus proof
+func (p *LocalParty) Round1() ([]*Message, error) {
+	if p.round != 0 {
+		return nil, errors.New("resharing: Round1 has already been run")
+	}
+	p.round = 1
+	i := p.index
+
+	dln1, dln2, err := p.preParams.DlnProof(p.params.session(i))
+	if err != nil {
+		return nil, err
+	}
+	modProof, err := p.preParams.PaillierSK.ModProof(p.params.session(i))
+	if err != nil {
+		return nil, err
+	}
+
+	pk := &p.preParams.PaillierSK.PublicKey
+	p.data.LocalPreParams = p.preParams
+	p.data.ShareID = p.params.NewPartyIDs[i]
+	p.data.Threshold = p.params.NewThreshold
+	copy(p.data.Ks, p.params.NewPartyIDs)
+	p.data.PaillierPKs[i] = pk
+	p.data.NTildej[i], p.data.H1j[i], p.data.H2j[i] = p.preParams.NTildei, p.preParams.H1i, p.preParams.H2i
+
+	modBzs := modProof.Bytes()
+	return []*Message{{
+		From: i,
+		To:   Broadcast,
+		Content: &DGRound1NewMessage{
+			PaillierN: pk.N,
+			NTilde:    p.preParams.NTildei,
+			H1:        p.preParams.H1i,
+			H2:        p.preParams.H2i,
+			Dln1:      dln1,
+			Dln2:      dln2,
+			ModProof:  modBzs[:],
+		},
+	}}, nil
+}
+
+// Round2 verifies the other new parties' parameters, then sends each of them a no small factor proof against its NTilde
+func (p *LocalParty) Round2() ([]*Message, error) {
+	if p.round != 1 || !p.canProceed() {
+		return nil, errors.New("resharing: Round2 is not ready")
+	}
+	p.round = 2
+	ec, i := p.params.EC, p.index
+
+	// 1. verify the other parties' ring-Pedersen parameters and Paillier moduli
+	h1H2Map := make(map[string]int, 2*p.params.NewPartyCount())
+	h1H2Map[p.preParams.H1i.String()] = i
+	h1H2Map[p.preParams.H2i.String()] = i
+	for j, msg := range p.temp.r1msgs {
+		if j == i {
+			continue
+		}
+		for _, h := range []*big.Int{msg.H1, msg.H2} {
+			if k, ok := h1H2Map[h.String()]; ok {
+				return nil, fmt.Errorf("resharing round 2: h1j or h2j of party %d was already used by party %d", j, k)
+			}
+			h1H2Map[h.String()] = j
+		}
+		paramsj := &proof.PaillierParams{
+			PaillierSK: &paillier.PrivateKey{PublicKey: paillier.PublicKey{N: msg.PaillierN}},
+			NTildei:    msg.NTilde,
+			H1i:        msg.H1,
+			H2i:        msg.H2,
+		}
+		if err := paramsj.VerifyDln(msg.Dln1, msg.Dln2, p.params.session(j)); err != nil {
+			return nil, fmt.Errorf("resharing round 2: dln proof from party %d failed: %v", j, err)
+		}
+		modProof, err := paillier.ModProofFromBytes(msg.ModProof)
+		if err == nil {
+			err = modProof.VerifyWithReason(msg.PaillierN, p.params.session(j))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("resharing round 2: mod proof from party %d failed: %v", j, err)
+		}
+		p.data.PaillierPKs[j] = &paramsj.PaillierSK.PublicKey
+		p.data.NTildej[j], p.data.H1j[j], p.data.H2j[j] = msg.NTilde, msg.H1, msg.H2
+	}
+
+	// 2. p2p: a no small factor proof against each party's NTilde
+	out := make([]*Message, 0, p.params.NewPartyCount()-1)
+	for j := range p.params.NewPartyIDs {
+		if j == i {
+			continue
+		}
+		fac, err := p.preParams.FacProof(ec, p.data.PaillierParamsj(j), p.params.session(i))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, &Message{From: i, To: j, Content: &DGRound2NewMessage{FacProof: fac}})
+	}
+	return out, nil
+}
+
+// finish verifies the no small factor proofs and the old parties' shares, then sums the shares into our new x_i and
+// computes every new public share X_j. Each old party must commit to lambda_j * X_j of its old public share, so the
+// commitments sum to the unchanged public key.
+func (p *LocalParty) finish() error {
+	if p.round != 2 || !p.canProceed() {
+		return errors.New("resharing: finish is not ready")
+	}
+	ec, i := p.params.EC, p.index
+	q := ec.Params().N
+	threshold := p.params.NewThreshold
+
+	// 1. no small factor proofs
+	for j, msg := range p.temp.r2msgs {
+		if j == i {
+			continue
+		}
+		if err := p.data.PaillierParamsj(j).VerifyFac(ec, p.preParams, msg.FacProof, p.params.session(j)); err != nil {
+			return fmt.Errorf("resharing finish: fac proof from party %d failed: %v", j, err)
+		}
+	}
+
+	// 2. the old parties' shares, and the commitments to the sum of their polynomials
+	var ecdsaPub *curve.ECPoint
+	var vc vss.Vs
+	xi := new(big.Int)
+	for j := range p.params.OldPartyIDs {
+		msg1, msg2 := p.temp.oldMsgs1[j], p.temp.oldMsgs2[j]
+		pub, err := curve.UnFlattenECPoints(ec, msg2.ECDSAPub)
+		if err != nil {
+			return fmt.Errorf("resharing finish: public key from old party %d is invalid: %v", j, err)
+		}
+		if ecdsaPub == nil {
+			ecdsaPub = pub[0]
+		} else if !ecdsaPub.Equals(pub[0]) {
+			return fmt.Errorf("resharing finish: old party %d sent a different public key", j)
+		}
+		vsj, err := vss.VsUnFlat(ec, msg2.Vs)
+		if err != nil || len(vsj) != threshold+1 {
+			return fmt.Errorf("resharing finish: vss commitments from old party %d are invalid", j)
+		}
+		// vsj[0] = g^(lambda_j * x_j)
+		lambda := vss.LagrangeCoefficient(ec, p.params.OldPartyIDs, j)
+		if Wj := p.params.OldBigXs[j].ScalarMult(lambda); Wj == nil || !Wj.Equals(vsj[0]) {
+			return fmt.Errorf("resharing finish: old party %d did not deal lambda_j * x_j of its old share", j)
+		}
+		share := &vss.Share{Threshold: threshold, ID: p.params.NewPartyIDs[i], Share: msg1.Share}
+		if !share.Verify(ec, threshold, vsj) {
+			return fmt.Errorf("resharing finish: vss share from old party %d failed", j)
+		}
+		if vc == nil {
+			vc = vsj
+		} else {
+			for c := range vc {
+				if vc[c], err = vc[c].Add(vsj[c]); err != nil {
+					return fmt.Errorf("resharing finish: summing the vss commitments failed: %v", err)
+				}
+			}
+		}
+		xi = xi.Add(xi, msg1.Share)
+	}
+	xi = xi.Mod(xi, q)
+	if !vc[0].Equals(ecdsaPub) {
+		return errors.New("resharing finish: the old parties' secrets do not sum to the public key")
+	}
+
+	// 3. X_j = prod vc_c^(k_j^c) for every new party
+	p.data.BigXj = make([]*curve.ECPoint, p.params.NewPartyCount())
+	for j, kj := range p.params.NewPartyIDs {
+		Xj, err := vc.EvaluateAt(ec, kj)
+		if err != nil {
+			return fmt.Errorf("resharing finish: computing X_j of party %d failed: %v", j, err)
+		}
+		p.data.BigXj[j] = Xj
+	}
+	if !curve.ScalarBaseMult(ec, xi).Equals(p.data.BigXj[i]) {
+		return errors.New("resharing finish: our share does not match X_i")
+	}
+	p.data.Xi = xi
+	p.data.ECDSAPub = ecdsaPub
+	p.done = true
+	return nil
+}
@@ -0,0 +1,196 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+// Chaum–Pedersen proof of discrete log equality log_G(A) = log_H(B) for arbitrary bases G and H.
+// Several statements can be proven together; their proofs then share a single Fiat–Shamir challenge
+// computed over all statements and commitments, and must be verified together with VerifyDLEQBatch.
+
+package schnorr
+
+import (
+	"errors"
+	"fmt"
+	"github.com/zhp12543/zk-proof/cmt"
+	"github.com/zhp12543/zk-proof/curve"
+	"math/big"
+)
+
+const (
+	DLEQProofBytesParts = 5
+)
+
+var (
+	dleqDomain = new(big.Int).SetBytes([]byte("zk-proof/dleq/v1"))
+)
+
+type (
+	// DLEQStatement claims that A = G^x and B = H^x for the same x
+	DLEQStatement struct {
+		G, H, A, B *curve.ECPoint
+	}
+
+	DLEQProof struct {
+		U, V *curve.ECPoint // G^r, H^r
+		Z    *big.Int       // r + c * x mod q
+	}
+)
+
+// NewDLEQProof proves log_G(A) = log_H(B) = x
+func NewDLEQProof(x *big.Int, st *DLEQStatement, optionalSession ...*cmt.Session) (*DLEQProof, error) {
+	pfs, err := NewDLEQBatchProof([]*big.Int{x}, []*DLEQStatement{st}, optionalSession...)
+	if err != nil {
+		return nil, err
+	}
+	return pfs[0], nil
+}
+
+// NewDLEQBatchProof proves every statement with its witness in xs using one shared challenge
+func NewDLEQBatchProof(xs []*big.Int, sts []*DLEQStatement, optionalSession ...*cmt.Session) ([]*DLEQProof, error) {
+	if len(xs) == 0 || len(xs) != len(sts) {
+		return nil, fmt.Errorf("NewDLEQBatchProof expected the same non-zero number of witnesses and statements but got %d and %d", len(xs), len(sts))
+	}
+	for i, st := range sts {
+		if xs[i] == nil || !st.ValidateBasic() {
+			return nil, fmt.Errorf("NewDLEQBatchProof received an invalid witness or statement at index %d", i)
+		}
+		if !sameCurve(st.G, sts[0].G) {
+			return nil, fmt.Errorf("NewDLEQBatchProof received a statement on another curve at index %d", i)
+		}
+	}
+	q := sts[0].G.Curve().Params().N
+	rs := make([]*big.Int, len(sts))
+	pfs := make([]*DLEQProof, len(sts))
+	for i, st := range sts {
+		for pfs[i] == nil {
+			rs[i] = curve.GetRandomPositiveInt(q)
+			U, V := st.G.ScalarMult(rs[i]), st.H.ScalarMult(rs[i])
+			if U == nil || V == nil || U.IsIdentity() || V.IsIdentity() {
+				continue
+			}
+			pfs[i] = &DLEQProof{U: U, V: V}
+		}
+	}
+	c := dleqChallenge(sts, pfs, cmt.OptionalSession(optionalSession))
+	for i, pf := range pfs {
+		z := new(big.Int).Mul(c, xs[i])
+		z = z.Add(rs[i], z)
+		pf.Z = z.Mod(z, q)
+	}
+	return pfs, nil
+}
+
+func NewDLEQProofFromBytes(st *DLEQStatement, bzs [][]byte) (*DLEQProof, error) {
+	if st == nil || st.G == nil {
+		return nil, errors.New("NewDLEQProofFromBytes received a nil statement")
+	}
+	if !curve.NonEmptyMultiBytes(bzs, DLEQProofBytesParts) {
+		return nil, fmt.Errorf("expected %d byte parts to construct DLEQProof", DLEQProofBytesParts)
+	}
+	return DLEQProofUnFlat(st.G.Curve(), curve.MultiBytesToBigInts(bzs))
+}
+
+// Verify checks a proof produced by NewDLEQProof
+func (pf *DLEQProof) Verify(st *DLEQStatement, optionalSession ...*cmt.Session) bool {
+	return VerifyDLEQBatch([]*DLEQStatement{st}, []*DLEQProof{pf}, optionalSession...)
+}
+
+// VerifyDLEQBatch checks proofs produced together by NewDLEQBatchProof, recomputing their shared challenge once
+func VerifyDLEQBatch(sts []*DLEQStatement, pfs []*DLEQProof, optionalSession ...*cmt.Session) bool {
+	if len(sts) == 0 || len(sts) != len(pfs) {
+		return false
+	}
+	for i, st := range sts {
+		pf := pfs[i]
+		if !st.ValidateBasic() || pf == nil || !pf.ValidateBasic() || pf.U.IsIdentity() || pf.V.IsIdentity() {
+			return false
+		}
+		// the shared challenge hashes every statement under the first one's curve
+		if !sameCurve(st.G, sts[0].G) {
+			return false
+		}
+		q := st.G.Curve().Params().N
+		if pf.Z.Sign() != 1 || pf.Z.Cmp(q) != -1 {
+			return false
+		}
+	}
+	c := dleqChallenge(sts, pfs, cmt.OptionalSession(optionalSession))
+	for i, st := range sts {
+		pf := pfs[i]
+		// G^z = U * A^c, H^z = V * B^c
+		if !dleqCheck(st.G, st.A, pf.U, pf.Z, c) || !dleqCheck(st.H, st.B, pf.V, pf.Z, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateBasic checks that all points are on one curve and none is the identity
+func (st *DLEQStatement) ValidateBasic() bool {
+	if st == nil {
+		return false
+	}
+	points := []*curve.ECPoint{st.G, st.H, st.A, st.B}
+	for _, point := range points {
+		if !point.ValidateBasic() || point.IsIdentity() {
+			return false
+		}
+	}
+	for _, point := range points[1:] {
+		if !sameCurve(point, st.G) {
+			return false
+		}
+	}
+	return true
+}
+
+func (pf *DLEQProof) ValidateBasic() bool {
+	return pf.U.ValidateBasic() && pf.V.ValidateBasic() && pf.Z != nil
+}
+
+func (pf *DLEQProof) Bytes() [DLEQProofBytesParts][]byte {
+	var out [DLEQProofBytesParts][]byte
+	for i, part := range pf.Flat() {
+		out[i] = part.Bytes()
+	}
+	return out
+}
+
+// ----- utils
+
+func sameCurve(a, b *curve.ECPoint) bool {
+	pa, pb := a.Curve().Params(), b.Curve().Params()
+	return pa.P.Cmp(pb.P) == 0 && pa.N.Cmp(pb.N) == 0
+}
+
+func dleqCheck(base, X, U *curve.ECPoint, z, c *big.Int) bool {
+	left := base.ScalarMult(z)
+	XC := X.ScalarMult(c)
+	if left == nil || XC == nil {
+		return false
+	}
+	right, err := XC.Add(U)
+	return err == nil && left.Equals(right)
+}
+
+// dleqChallenge derives the c shared by every proof of a batch
+func dleqChallenge(sts []*DLEQStatement, pfs []*DLEQProof, session *cmt.Session) *big.Int {
+	params := sts[0].G.Curve().Params()
+	statement := []*big.Int{params.P, params.N}
+	commitment := make([]*big.Int, 0, len(pfs)*4)
+	legacy := []*big.Int{dleqDomain, params.P, params.N, big.NewInt(int64(len(sts)))}
+	for i, st := range sts {
+		for _, point := range []*curve.ECPoint{st.G, st.H, st.A, st.B} {
+			statement = append(statement, point.X(), point.Y())
+			legacy = append(legacy, point.X(), point.Y())
+		}
+		for _, point := range []*curve.ECPoint{pfs[i].U, pfs[i].V} {
+			commitment = append(commitment, point.X(), point.Y())
+			legacy = append(legacy, point.X(), point.Y())
+		}
+	}
+	ch := &cmt.Challenge{Domain: "zk-proof/dleq/v1", Statement: statement, Commitment: commitment, Legacy: legacy}
+	return ch.Scalar(session, params.N)
+}
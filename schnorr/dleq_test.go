@@ -0,0 +1,111 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package schnorr
+
+import (
+	"crypto/elliptic"
+	"github.com/decred/dcrd/dcrec/edwards"
+	"github.com/zhp12543/zk-proof/curve"
+	"math/big"
+	"testing"
+)
+
+func randomStatement(ec elliptic.Curve) (*big.Int, *DLEQStatement) {
+	q := ec.Params().N
+	x := curve.GetRandomPositiveInt(q)
+	G := curve.ScalarBaseMult(ec, curve.GetRandomPositiveInt(q))
+	H := curve.ScalarBaseMult(ec, curve.GetRandomPositiveInt(q))
+	return x, &DLEQStatement{G: G, H: H, A: G.ScalarMult(x), B: H.ScalarMult(x)}
+}
+
+func TestDLEQProof(t *testing.T) {
+	for _, ec := range []elliptic.Curve{elliptic.P256(), edwards.Edwards()} {
+		name := ec.Params().Name
+		x, st := randomStatement(ec)
+		pf, err := NewDLEQProof(x, st)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		if !pf.Verify(st) {
+			t.Fatal(name, "DLEQProof did not verify")
+		}
+		bzs := pf.Bytes()
+		pf2, err := NewDLEQProofFromBytes(st, bzs[:])
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		if !pf2.Verify(st) {
+			t.Fatal(name, "DLEQProof did not verify after a bytes round trip")
+		}
+
+		// B = H^(x+1)
+		bad := *st
+		bad.B = st.H.ScalarMult(new(big.Int).Add(x, big.NewInt(1)))
+		if pf.Verify(&bad) {
+			t.Error(name, "DLEQProof verified for unequal logs")
+		}
+		pf3, err := NewDLEQProof(x, &bad)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		if pf3.Verify(&bad) {
+			t.Error(name, "DLEQProof proved unequal logs")
+		}
+		noG := *st
+		noG.G = nil
+		if pf.Verify(&noG) {
+			t.Error(name, "DLEQProof verified with a nil G")
+		}
+	}
+}
+
+func TestDLEQBatchProof(t *testing.T) {
+	ec := elliptic.P256()
+	const n = 4
+	xs := make([]*big.Int, n)
+	sts := make([]*DLEQStatement, n)
+	for i := range sts {
+		xs[i], sts[i] = randomStatement(ec)
+	}
+	pfs, err := NewDLEQBatchProof(xs, sts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyDLEQBatch(sts, pfs) {
+		t.Fatal("DLEQ batch did not verify")
+	}
+	// the proofs are bound together by the shared challenge
+	if pfs[0].Verify(sts[0]) {
+		t.Error("a single proof from a batch verified on its own")
+	}
+	if VerifyDLEQBatch(sts[:n-1], pfs[:n-1]) {
+		t.Error("a truncated DLEQ batch verified")
+	}
+	sts[1], sts[2] = sts[2], sts[1]
+	if VerifyDLEQBatch(sts, pfs) {
+		t.Error("a DLEQ batch verified with statements out of order")
+	}
+}
+
+func TestDLEQBatchProofMixedCurves(t *testing.T) {
+	x1, st1 := randomStatement(elliptic.P256())
+	x2, st2 := randomStatement(edwards.Edwards())
+	if _, err := NewDLEQBatchProof([]*big.Int{x1, x2}, []*DLEQStatement{st1, st2}); err == nil {
+		t.Error("NewDLEQBatchProof accepted statements on different curves")
+	}
+	pf1, err := NewDLEQProof(x1, st1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pf2, err := NewDLEQProof(x2, st2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if VerifyDLEQBatch([]*DLEQStatement{st1, st2}, []*DLEQProof{pf1, pf2}) {
+		t.Error("a DLEQ batch verified with statements on different curves")
+	}
+}
@@ -0,0 +1,68 @@
+package schnorr
+
+import (
+	"crypto/elliptic"
+	"fmt"
+	"github.com/zhp12543/zk-proof/curve"
+	"math/big"
+)
+
+func (pf *ZKProof) Flat() []*big.Int {
+	return []*big.Int{
+		pf.Alpha.X(),
+		pf.Alpha.Y(),
+		pf.T,
+	}
+}
+
+func ZKProofUnFlat(ec elliptic.Curve, in []*big.Int) (*ZKProof, error) {
+	if len(in) != ZKProofBytesParts {
+		return nil, fmt.Errorf("expected %d big.Int parts to construct ZKProof", ZKProofBytesParts)
+	}
+	point, err := curve.NewECPoint(ec, in[0], in[1])
+	if err != nil {
+		return nil, err
+	}
+	return &ZKProof{Alpha: point, T: in[2]}, nil
+}
+
+func (pf *ZKVProof) Flat() []*big.Int {
+	return []*big.Int{
+		pf.Alpha.X(),
+		pf.Alpha.Y(),
+		pf.T,
+		pf.U,
+	}
+}
+
+func ZKVProofUnFlat(ec elliptic.Curve, in []*big.Int) (*ZKVProof, error) {
+	if len(in) != ZKVProofBytesParts {
+		return nil, fmt.Errorf("expected %d big.Int parts to construct ZKVProof", ZKVProofBytesParts)
+	}
+	point, err := curve.NewECPoint(ec, in[0], in[1])
+	if err != nil {
+		return nil, err
+	}
+	return &ZKVProof{Alpha: point, T: in[2], U: in[3]}, nil
+}
+
+func (pf *DLEQProof) Flat() []*big.Int {
+	return []*big.Int{
+		pf.U.X(),
+		pf.U.Y(),
+		pf.V.X(),
+		pf.V.Y(),
+		pf.Z,
+	}
+}
+
+func DLEQProofUnFlat(ec elliptic.Curve, in []*big.Int) (*DLEQProof, error) {
+	if len(in) != DLEQProofBytesParts {
+		return nil, fmt.Errorf("expected %d big.Int parts to construct DLEQProof", DLEQProofBytesParts)
+	}
+	points, err := curve.UnFlattenECPoints(ec, in[:4])
+	if err != nil {
+		return nil, err
+	}
+	return &DLEQProof{U: points[0], V: points[1], Z: in[4]}, nil
+}
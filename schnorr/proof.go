@@ -0,0 +1,212 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+// Non-interactive Schnorr proof of knowledge of x for X = g^x, made non-interactive with Fiat–Shamir.
+// It only relies on the elliptic.Curve interface, so it works for the NIST curves as well as the edwards curve.
+
+package schnorr
+
+import (
+	"errors"
+	"fmt"
+	"github.com/zhp12543/zk-proof/cmt"
+	"github.com/zhp12543/zk-proof/curve"
+	"math/big"
+)
+
+const (
+	ZKProofBytesParts  = 3
+	ZKVProofBytesParts = 4
+)
+
+var (
+	// domain separates Schnorr challenges from the other proofs hashed with cmt.SHA512_256i
+	domain  = new(big.Int).SetBytes([]byte("zk-proof/schnorr/v1"))
+	vDomain = new(big.Int).SetBytes([]byte("zk-proof/schnorr-v/v1"))
+)
+
+type (
+	ZKProof struct {
+		Alpha *curve.ECPoint // g^a
+		T     *big.Int       // a + c * x mod q
+	}
+
+	// ZKVProof proves knowledge of s, l such that V = R^s * g^l (GG18 Fig. 5, phase 5B)
+	ZKVProof struct {
+		Alpha *curve.ECPoint // R^a * g^b
+		T, U  *big.Int       // a + c * s, b + c * l mod q
+	}
+)
+
+// NewZKProof proves knowledge of x such that X = g^x on the curve of X
+func NewZKProof(x *big.Int, X *curve.ECPoint, optionalSession ...*cmt.Session) (*ZKProof, error) {
+	if x == nil || !X.ValidateBasic() || X.IsIdentity() {
+		return nil, errors.New("NewZKProof received an invalid argument")
+	}
+	ec := X.Curve()
+	q := ec.Params().N
+	var a *big.Int
+	var alpha *curve.ECPoint
+	for alpha == nil || alpha.IsIdentity() {
+		a = curve.GetRandomPositiveInt(q)
+		alpha = curve.ScalarBaseMult(ec, a)
+	}
+	c := challenge(X, alpha, cmt.OptionalSession(optionalSession))
+	t := new(big.Int).Mul(c, x)
+	t = t.Add(a, t)
+	t = t.Mod(t, q)
+	return &ZKProof{Alpha: alpha, T: t}, nil
+}
+
+func NewZKProofFromBytes(X *curve.ECPoint, bzs [][]byte) (*ZKProof, error) {
+	if X == nil {
+		return nil, errors.New("NewZKProofFromBytes received a nil point")
+	}
+	if !curve.NonEmptyMultiBytes(bzs, ZKProofBytesParts) {
+		return nil, fmt.Errorf("expected %d byte parts to construct ZKProof", ZKProofBytesParts)
+	}
+	return ZKProofUnFlat(X.Curve(), curve.MultiBytesToBigInts(bzs))
+}
+
+// Verify checks the proof of knowledge of log_g(X); off-curve and identity points are rejected
+func (pf *ZKProof) Verify(X *curve.ECPoint, optionalSession ...*cmt.Session) bool {
+	if pf == nil || !pf.ValidateBasic() || !X.ValidateBasic() || X.IsIdentity() || pf.Alpha.IsIdentity() {
+		return false
+	}
+	ec := X.Curve()
+	q := ec.Params().N
+	if pf.T.Sign() != 1 || pf.T.Cmp(q) != -1 {
+		return false
+	}
+	c := challenge(X, pf.Alpha, cmt.OptionalSession(optionalSession))
+	// g^t = alpha * X^c
+	left := curve.ScalarBaseMult(ec, pf.T)
+	XC := X.ScalarMult(c)
+	if left == nil || XC == nil {
+		return false
+	}
+	right, err := XC.Add(pf.Alpha)
+	if err != nil {
+		return false
+	}
+	return left.Equals(right)
+}
+
+func (pf *ZKProof) ValidateBasic() bool {
+	return pf.Alpha.ValidateBasic() && pf.T != nil
+}
+
+func (pf *ZKProof) Bytes() [ZKProofBytesParts][]byte {
+	var out [ZKProofBytesParts][]byte
+	for i, part := range pf.Flat() {
+		out[i] = part.Bytes()
+	}
+	return out
+}
+
+// ----- //
+
+// NewZKVProof proves knowledge of s and l such that V = R^s * g^l
+func NewZKVProof(V, R *curve.ECPoint, s, l *big.Int, optionalSession ...*cmt.Session) (*ZKVProof, error) {
+	if s == nil || l == nil || !V.ValidateBasic() || !R.ValidateBasic() || R.IsIdentity() {
+		return nil, errors.New("NewZKVProof received an invalid argument")
+	}
+	ec := R.Curve()
+	q := ec.Params().N
+	var a, b *big.Int
+	var alpha *curve.ECPoint
+	for alpha == nil || alpha.IsIdentity() {
+		a, b = curve.GetRandomPositiveInt(q), curve.GetRandomPositiveInt(q)
+		aR, bG := R.ScalarMult(a), curve.ScalarBaseMult(ec, b)
+		if aR == nil || bG == nil {
+			continue
+		}
+		alpha, _ = aR.Add(bG)
+	}
+	c := vChallenge(V, R, alpha, cmt.OptionalSession(optionalSession))
+	t := new(big.Int).Mul(c, s)
+	t = t.Add(a, t)
+	t = t.Mod(t, q)
+	u := new(big.Int).Mul(c, l)
+	u = u.Add(b, u)
+	u = u.Mod(u, q)
+	return &ZKVProof{Alpha: alpha, T: t, U: u}, nil
+}
+
+func NewZKVProofFromBytes(R *curve.ECPoint, bzs [][]byte) (*ZKVProof, error) {
+	if R == nil {
+		return nil, errors.New("NewZKVProofFromBytes received a nil point")
+	}
+	if !curve.NonEmptyMultiBytes(bzs, ZKVProofBytesParts) {
+		return nil, fmt.Errorf("expected %d byte parts to construct ZKVProof", ZKVProofBytesParts)
+	}
+	return ZKVProofUnFlat(R.Curve(), curve.MultiBytesToBigInts(bzs))
+}
+
+// Verify checks R^t * g^u = alpha * V^c; off-curve and identity points are rejected
+func (pf *ZKVProof) Verify(V, R *curve.ECPoint, optionalSession ...*cmt.Session) bool {
+	if pf == nil || !pf.ValidateBasic() || !V.ValidateBasic() || !R.ValidateBasic() ||
+		V.IsIdentity() || R.IsIdentity() || pf.Alpha.IsIdentity() {
+		return false
+	}
+	ec := R.Curve()
+	q := ec.Params().N
+	if pf.T.Sign() != 1 || pf.T.Cmp(q) != -1 || pf.U.Sign() != 1 || pf.U.Cmp(q) != -1 {
+		return false
+	}
+	c := vChallenge(V, R, pf.Alpha, cmt.OptionalSession(optionalSession))
+	tR, uG, VC := R.ScalarMult(pf.T), curve.ScalarBaseMult(ec, pf.U), V.ScalarMult(c)
+	if tR == nil || uG == nil || VC == nil {
+		return false
+	}
+	left, err := tR.Add(uG)
+	if err != nil {
+		return false
+	}
+	right, err := VC.Add(pf.Alpha)
+	if err != nil {
+		return false
+	}
+	return left.Equals(right)
+}
+
+func (pf *ZKVProof) ValidateBasic() bool {
+	return pf.Alpha.ValidateBasic() && pf.T != nil && pf.U != nil
+}
+
+func (pf *ZKVProof) Bytes() [ZKVProofBytesParts][]byte {
+	var out [ZKVProofBytesParts][]byte
+	for i, part := range pf.Flat() {
+		out[i] = part.Bytes()
+	}
+	return out
+}
+
+// ----- utils
+
+// challenge derives c for X = G^x, binding the curve along with the statement
+func challenge(X, alpha *curve.ECPoint, session *cmt.Session) *big.Int {
+	params := X.Curve().Params()
+	ch := &cmt.Challenge{
+		Domain:     "zk-proof/schnorr/v1",
+		Statement:  []*big.Int{params.P, params.N, params.Gx, params.Gy, X.X(), X.Y()},
+		Commitment: []*big.Int{alpha.X(), alpha.Y()},
+		Legacy:     []*big.Int{domain, params.P, params.N, params.Gx, params.Gy, X.X(), X.Y(), alpha.X(), alpha.Y()},
+	}
+	return ch.Scalar(session, params.N)
+}
+
+// vChallenge derives c for V = R^s * g^l
+func vChallenge(V, R, alpha *curve.ECPoint, session *cmt.Session) *big.Int {
+	params := R.Curve().Params()
+	ch := &cmt.Challenge{
+		Domain:     "zk-proof/schnorr-v/v1",
+		Statement:  []*big.Int{params.P, params.N, params.Gx, params.Gy, V.X(), V.Y(), R.X(), R.Y()},
+		Commitment: []*big.Int{alpha.X(), alpha.Y()},
+		Legacy:     []*big.Int{vDomain, params.P, params.N, params.Gx, params.Gy, V.X(), V.Y(), R.X(), R.Y(), alpha.X(), alpha.Y()},
+	}
+	return ch.Scalar(session, params.N)
+}
@@ -0,0 +1,154 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package schnorr
+
+import (
+	"crypto/elliptic"
+	"github.com/decred/dcrd/dcrec/edwards"
+	"github.com/zhp12543/zk-proof/cmt"
+	"github.com/zhp12543/zk-proof/curve"
+	"math/big"
+	"testing"
+)
+
+func TestZKProof(t *testing.T) {
+	for _, ec := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), edwards.Edwards()} {
+		name := ec.Params().Name
+		q := ec.Params().N
+		x := curve.GetRandomPositiveInt(q)
+		X := curve.ScalarBaseMult(ec, x)
+
+		pf, err := NewZKProof(x, X)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		if !pf.Verify(X) {
+			t.Fatal(name, "ZKProof did not verify")
+		}
+		bzs := pf.Bytes()
+		pf2, err := NewZKProofFromBytes(X, bzs[:])
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		if !pf2.Verify(X) {
+			t.Fatal(name, "ZKProof did not verify after a bytes round trip")
+		}
+
+		Y := curve.ScalarBaseMult(ec, new(big.Int).Add(x, big.NewInt(1)))
+		if pf.Verify(Y) {
+			t.Error(name, "ZKProof verified for the wrong point")
+		}
+		pf3, err := NewZKProof(new(big.Int).Add(x, big.NewInt(1)), X)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		if pf3.Verify(X) {
+			t.Error(name, "ZKProof verified for the wrong witness")
+		}
+	}
+}
+
+func TestZKProofRejectsIdentity(t *testing.T) {
+	ec := edwards.Edwards()
+	identity, err := curve.NewECPoint(ec, big.NewInt(0), big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !identity.IsIdentity() {
+		t.Fatal("expected (0, 1) to be the edwards identity")
+	}
+	if _, err := NewZKProof(big.NewInt(0), identity); err == nil {
+		t.Error("NewZKProof accepted the identity point")
+	}
+	x := curve.GetRandomPositiveInt(ec.Params().N)
+	pf, err := NewZKProof(x, curve.ScalarBaseMult(ec, x))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pf.Verify(identity) {
+		t.Error("ZKProof verified for the identity point")
+	}
+}
+
+func TestZKVProof(t *testing.T) {
+	ec := elliptic.P256()
+	q := ec.Params().N
+	R := curve.ScalarBaseMult(ec, curve.GetRandomPositiveInt(q))
+	s, l := curve.GetRandomPositiveInt(q), curve.GetRandomPositiveInt(q)
+	V, err := R.ScalarMult(s).Add(curve.ScalarBaseMult(ec, l))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pf, err := NewZKVProof(V, R, s, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pf.Verify(V, R) {
+		t.Fatal("ZKVProof did not verify")
+	}
+	bzs := pf.Bytes()
+	pf2, err := NewZKVProofFromBytes(R, bzs[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pf2.Verify(V, R) {
+		t.Fatal("ZKVProof did not verify after a bytes round trip")
+	}
+	if pf.Verify(R.ScalarMult(s), R) {
+		t.Error("ZKVProof verified for the wrong V")
+	}
+}
+
+func TestProofsSession(t *testing.T) {
+	ec := elliptic.P256()
+	q := ec.Params().N
+	session, _ := cmt.NewSession([]byte("session"), big.NewInt(1))
+	other, _ := cmt.NewSession([]byte("session"), big.NewInt(2))
+
+	x := curve.GetRandomPositiveInt(q)
+	X := curve.ScalarBaseMult(ec, x)
+	pf, err := NewZKProof(x, X, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pf.Verify(X, session) {
+		t.Fatal("ZKProof did not verify in its session")
+	}
+	if pf.Verify(X, other) || pf.Verify(X) {
+		t.Error("ZKProof verified outside its session")
+	}
+
+	R := curve.ScalarBaseMult(ec, curve.GetRandomPositiveInt(q))
+	s, l := curve.GetRandomPositiveInt(q), curve.GetRandomPositiveInt(q)
+	V, err := R.ScalarMult(s).Add(curve.ScalarBaseMult(ec, l))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vPf, err := NewZKVProof(V, R, s, l, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !vPf.Verify(V, R, session) {
+		t.Fatal("ZKVProof did not verify in its session")
+	}
+	if vPf.Verify(V, R, other) || vPf.Verify(V, R) {
+		t.Error("ZKVProof verified outside its session")
+	}
+
+	x, st := randomStatement(ec)
+	dPf, err := NewDLEQProof(x, st, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dPf.Verify(st, session) {
+		t.Fatal("DLEQProof did not verify in its session")
+	}
+	if dPf.Verify(st, other) || dPf.Verify(st) {
+		t.Error("DLEQProof verified outside its session")
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+// Package signing implements the GG18 threshold ECDSA signing protocol (Gennaro, R., Goldfeder, S.: Fast Multiparty
+// Threshold ECDSA with Fast Trustless Setup, Section 4.3) for t+1 of the n parties holding a keygen share,
+// as a message-driven state machine for one signer.
+//
+// Usage: call Round1 and deliver its messages, then pass every message received from the other signers to Update
+// and deliver whatever it returns, until Outputs reports that the signature is ready.
+
+package signing
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"github.com/zhp12543/zk-proof/cmt"
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/keygen"
+	"github.com/zhp12543/zk-proof/vss"
+	"math/big"
+)
+
+type (
+	Parameters struct {
+		EC        elliptic.Curve
+		PartyIDs  []*big.Int // the keygen Shamir indexes of the signers, in the same order for all signers
+		Index     int        // our position in PartyIDs
+		Threshold int        // t; exactly t+1 signers take part
+		SessionID []byte     // unique per signing and agreed on by all signers; binds every proof to this signing
+	}
+
+	LocalParty struct {
+		params *Parameters
+		key    *keygen.LocalPartySaveData
+		m      *big.Int // the message hash
+		round  int      // the last round started, 0 before Round1
+		temp   localTempData
+		data   SignatureData
+		done   bool
+	}
+
+	// SignatureData is the ECDSA signature (R, S) of M
+	SignatureData struct {
+		R, S, M *big.Int
+	}
+
+	msgKey struct {
+		round     int
+		broadcast bool
+	}
+
+	localTempData struct {
+		keyIdx []int // position of each signer in key.Ks
+		wi     *big.Int
+		bigWs  []*curve.ECPoint
+
+		ki, gammaI, deltaI, sigmaI, si, li, rhoI *big.Int
+		bigGammaI, bigVi, bigAi, bigUi, bigTi    *curve.ECPoint
+		gammaDeCommit, vaDeCommit, utDeCommit    cmt.HashDeCommitment
+		cAs, betas, nus                          []*big.Int       // per signer
+		bigAjs                                   []*curve.ECPoint // per signer
+
+		delta, r         *big.Int
+		bigR, bigV, bigA *curve.ECPoint
+
+		received map[msgKey][]MessageContent // indexed by sender
+	}
+)
+
+var (
+	// the messages every other signer sends in each round
+	roundMessages = map[int][]msgKey{
+		1: {{1, false}, {1, true}},
+		2: {{2, false}},
+		3: {{3, true}},
+		4: {{4, true}},
+		5: {{5, true}},
+		6: {{6, true}},
+		7: {{7, true}},
+		8: {{8, true}},
+		9: {{9, true}},
+	}
+)
+
+func NewParameters(ec elliptic.Curve, partyIDs []*big.Int, index, threshold int, sessionID []byte) (*Parameters, error) {
+	if ec == nil {
+		return nil, errors.New("signing: nil curve")
+	}
+	if threshold < 1 || len(partyIDs) != threshold+1 {
+		return nil, fmt.Errorf("signing: expected %d signers but got %d", threshold+1, len(partyIDs))
+	}
+	if index < 0 || len(partyIDs) <= index {
+		return nil, fmt.Errorf("signing: party index %d out of range", index)
+	}
+	if len(sessionID) == 0 {
+		return nil, errors.New("signing: empty session ID")
+	}
+	if err := vss.CheckIndexes(ec, partyIDs); err != nil {
+		return nil, err
+	}
+	return &Parameters{EC: ec, PartyIDs: partyIDs, Index: index, Threshold: threshold, SessionID: sessionID}, nil
+}
+
+func (params *Parameters) PartyCount() int {
+	return len(params.PartyIDs)
+}
+
+// session binds the proofs made by signer j to this signing
+func (params *Parameters) session(j int) *cmt.Session {
+	return &cmt.Session{ID: params.SessionID, Party: params.PartyIDs[j]}
+}
+
+// NewLocalParty creates the state machine of one signer with a keygen share.
+// m is the message hash as an integer, already truncated to the bit length of the curve order as crypto/ecdsa does.
+func NewLocalParty(params *Parameters, key *keygen.LocalPartySaveData, m *big.Int) (*LocalParty, error) {
+	if params == nil || key == nil || key.LocalPreParams == nil || key.Xi == nil || key.ECDSAPub == nil || m == nil {
+		return nil, errors.New("signing: NewLocalParty received nil params")
+	}
+	if m.Sign() == -1 || params.EC.Params().N.BitLen() < m.BitLen() {
+		return nil, errors.New("signing: the message hash is longer than the curve order")
+	}
+	if key.Threshold != params.Threshold {
+		return nil, fmt.Errorf("signing: the key was generated for threshold %d", key.Threshold)
+	}
+	if key.ShareID.Cmp(params.PartyIDs[params.Index]) != 0 {
+		return nil, errors.New("signing: the key does not belong to our party ID")
+	}
+	ec := params.EC
+	n := params.PartyCount()
+	p := &LocalParty{params: params, key: key, m: m}
+	p.temp.received = make(map[msgKey][]MessageContent, 10)
+
+	// convert the (t,n) share x_i to an additive share w_i among the signers, and W_j = g^w_j for all signers
+	p.temp.keyIdx = make([]int, n)
+	p.temp.bigWs = make([]*curve.ECPoint, n)
+	for j, id := range params.PartyIDs {
+		k := indexOf(key.Ks, id)
+		if k < 0 {
+			return nil, fmt.Errorf("signing: signer %d is not a party of the key", j)
+		}
+		p.temp.keyIdx[j] = k
+		lambda := vss.LagrangeCoefficient(ec, params.PartyIDs, j)
+		p.temp.bigWs[j] = key.BigXj[k].ScalarMult(lambda)
+		if j == params.Index {
+			p.temp.wi = new(big.Int).Mul(key.Xi, lambda)
+			p.temp.wi = p.temp.wi.Mod(p.temp.wi, ec.Params().N)
+		}
+	}
+	return p, nil
+}
+
+// Update stores a message from another signer. Once every message of the current round has arrived it runs the
+// next round and returns that round's outgoing messages, which may be none.
+func (p *LocalParty) Update(msg *Message) ([]*Message, error) {
+	if err := p.storeMessage(msg); err != nil {
+		return nil, err
+	}
+	rounds := []func() ([]*Message, error){p.Round2, p.Round3, p.Round4, p.Round5, p.Round6, p.Round7, p.Round8, p.Round9}
+	var out []*Message
+	for !p.done && p.canProceed() {
+		var msgs []*Message
+		var err error
+		if p.round <= len(rounds) {
+			msgs, err = rounds[p.round-1]()
+		} else {
+			err = p.finish()
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, msgs...)
+	}
+	return out, nil
+}
+
+// Outputs returns the signature once signing has finished
+func (p *LocalParty) Outputs() (*SignatureData, bool) {
+	if !p.done {
+		return nil, false
+	}
+	return &p.data, true
+}
+
+func (p *LocalParty) storeMessage(msg *Message) error {
+	if msg == nil || msg.Content == nil || !msg.Content.ValidateBasic() {
+		return errors.New("signing: received a malformed message")
+	}
+	from := msg.From
+	if from < 0 || p.params.PartyCount() <= from || from == p.params.Index {
+		return fmt.Errorf("signing: received a message from invalid party %d", from)
+	}
+	if msg.Content.IsBroadcast() != (msg.To == Broadcast) || (msg.To != Broadcast && msg.To != p.params.Index) {
+		return fmt.Errorf("signing: received a misrouted message from party %d", from)
+	}
+	key := msgKey{msg.Content.RoundNumber(), msg.Content.IsBroadcast()}
+	msgs, ok := p.temp.received[key]
+	if !ok {
+		msgs = make([]MessageContent, p.params.PartyCount())
+		p.temp.received[key] = msgs
+	}
+	if msgs[from] != nil {
+		return fmt.Errorf("signing: received a duplicate round %d message from party %d", key.round, from)
+	}
+	msgs[from] = msg.Content
+	return nil
+}
+
+// canProceed reports whether every other signer's messages for the current round have arrived
+func (p *LocalParty) canProceed() bool {
+	keys, ok := roundMessages[p.round]
+	if !ok {
+		return false
+	}
+	for _, key := range keys {
+		msgs := p.temp.received[key]
+		if msgs == nil {
+			return false
+		}
+		for j, msg := range msgs {
+			if j != p.params.Index && msg == nil {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// message returns the message of the given kind received from signer j
+func (p *LocalParty) message(round int, broadcast bool, j int) MessageContent {
+	return p.temp.received[msgKey{round, broadcast}][j]
+}
+
+// ----- utils
+
+func indexOf(ids []*big.Int, id *big.Int) int {
+	for i, v := range ids {
+		if v != nil && v.Cmp(id) == 0 {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,86 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package signing_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"errors"
+	"github.com/zhp12543/zk-proof/abort"
+	"github.com/zhp12543/zk-proof/keygen"
+	"github.com/zhp12543/zk-proof/mta"
+	"github.com/zhp12543/zk-proof/signing"
+	"github.com/zhp12543/zk-proof/test"
+	"math/big"
+	"testing"
+)
+
+const (
+	testParties   = 3
+	testThreshold = 1
+)
+
+func TestE2E(t *testing.T) {
+	ec := elliptic.P256()
+	keys, err := test.RunKeygen(ec, testParties, testThreshold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &ecdsa.PublicKey{Curve: ec, X: keys[0].ECDSAPub.X(), Y: keys[0].ECDSAPub.Y()}
+
+	hash := sha256.Sum256([]byte("hello, threshold ECDSA"))
+	m := new(big.Int).SetBytes(hash[:])
+	// any t+1 of the n parties can sign
+	for _, signers := range [][]*keygen.LocalPartySaveData{{keys[0], keys[2]}, {keys[2], keys[1]}} {
+		sigs, err := test.RunSigning(ec, signers, m, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, sig := range sigs {
+			if sig.R.Cmp(sigs[0].R) != 0 || sig.S.Cmp(sigs[0].S) != 0 {
+				t.Fatalf("signer %d output a different signature", i)
+			}
+		}
+		if !ecdsa.Verify(pub, hash[:], sigs[0].R, sigs[0].S) {
+			t.Fatal("the signature does not verify with crypto/ecdsa")
+		}
+	}
+
+	// a tampered MtA reply is blamed on its sender
+	_, err = test.RunSigning(ec, []*keygen.LocalPartySaveData{keys[0], keys[1]}, m, func(msg *signing.Message) {
+		if content, ok := msg.Content.(*signing.SignRound2Message); ok && msg.From == 1 {
+			content.C1 = new(big.Int).Add(content.C1, big.NewInt(1))
+		}
+	})
+	var abortErr *abort.Error
+	if !errors.As(err, &abortErr) {
+		t.Fatalf("expected an abort.Error, got %v", err)
+	}
+	if abortErr.Round != 3 || len(abortErr.Culprits) != 1 {
+		t.Fatalf("unexpected culprit report: %v", abortErr)
+	}
+	if c := abortErr.Culprits[0]; c.Party != 1 || c.Proof != "ProofBob" || c.Step != mta.StepVerify || len(c.MsgHash) == 0 {
+		t.Fatalf("unexpected culprit report: %v", abortErr)
+	}
+
+	// a ciphertext that does not match its range proof is blamed on its sender in round 2
+	_, err = test.RunSigning(ec, []*keygen.LocalPartySaveData{keys[0], keys[1]}, m, func(msg *signing.Message) {
+		if content, ok := msg.Content.(*signing.SignRound1Message1); ok && msg.From == 1 {
+			content.C = new(big.Int).Add(content.C, big.NewInt(1))
+		}
+	})
+	if !errors.As(err, &abortErr) {
+		t.Fatalf("expected an abort.Error, got %v", err)
+	}
+	if abortErr.Round != 2 || len(abortErr.Culprits) != 1 {
+		t.Fatalf("unexpected culprit report: %v", abortErr)
+	}
+	if c := abortErr.Culprits[0]; c.Party != 1 || c.Proof != "RangeProofAlice" || c.Step != mta.StepVerify {
+		t.Fatalf("unexpected culprit report: %v", abortErr)
+	}
+}
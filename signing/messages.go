@@ -0,0 +1,195 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package signing
+
+import (
+	"github.com/zhp12543/zk-proof/cmt"
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/mta"
+	"github.com/zhp12543/zk-proof/schnorr"
+	"math/big"
+)
+
+const (
+	// Broadcast is the Message.To value of a message sent to every other signer
+	Broadcast = -1
+)
+
+type (
+	// Message is routed by the caller from signer `From` to signer `To`, or to every other signer if To is Broadcast
+	Message struct {
+		From, To int // positions in Parameters.PartyIDs
+		Content  MessageContent
+	}
+
+	MessageContent interface {
+		RoundNumber() int
+		IsBroadcast() bool
+		ValidateBasic() bool
+	}
+
+	// SignRound1Message1 is Alice's first MtA message for k_i, made against the recipient's NTilde
+	SignRound1Message1 struct {
+		C          *big.Int
+		RangeProof [][]byte
+	}
+
+	// SignRound1Message2 commits to Gamma_i = g^gamma_i
+	SignRound1Message2 struct {
+		Commitment *big.Int
+	}
+
+	// SignRound2Message is Bob's reply to both MtA instances: k_j * gamma_i and k_j * w_i (with check)
+	SignRound2Message struct {
+		C1         *big.Int
+		ProofBob   [][]byte
+		C2         *big.Int
+		ProofBobWC [][]byte
+	}
+
+	// SignRound3Message reveals delta_i
+	SignRound3Message struct {
+		Delta *big.Int
+	}
+
+	// SignRound4Message opens the round 1 commitment to Gamma_i
+	SignRound4Message struct {
+		DeCommitment []*big.Int
+		GammaProof   [][]byte // Schnorr proof for gamma_i = log_g(Gamma_i)
+	}
+
+	// SignRound5Message commits to V_i = R^s_i * g^l_i and A_i = g^rho_i (phase 5A)
+	SignRound5Message struct {
+		Commitment *big.Int
+	}
+
+	// SignRound6Message opens the round 5 commitment (phase 5B)
+	SignRound6Message struct {
+		DeCommitment []*big.Int
+		VProof       [][]byte // proof of s_i, l_i
+		AProof       [][]byte // Schnorr proof for rho_i = log_g(A_i)
+	}
+
+	// SignRound7Message commits to U_i = V^rho_i and T_i = A^l_i (phase 5C)
+	SignRound7Message struct {
+		Commitment *big.Int
+	}
+
+	// SignRound8Message opens the round 7 commitment (phase 5D)
+	SignRound8Message struct {
+		DeCommitment []*big.Int
+		UProof       [][]byte // DLEQ proof for log_g(A_i) = log_V(U_i)
+	}
+
+	// SignRound9Message reveals s_i (phase 5E)
+	SignRound9Message struct {
+		Si *big.Int
+	}
+)
+
+func (m *SignRound1Message1) RoundNumber() int  { return 1 }
+func (m *SignRound1Message1) IsBroadcast() bool { return false }
+
+func (m *SignRound1Message1) ValidateBasic() bool {
+	return m != nil && m.C != nil && curve.NonEmptyMultiBytes(m.RangeProof, mta.RangeProofAliceBytesParts)
+}
+
+// Hash returns the message hash used in culprit reports
+func (m *SignRound1Message1) Hash() []byte {
+	return cmt.SHA512_256(append([][]byte{m.C.Bytes()}, m.RangeProof...)...)
+}
+
+func (m *SignRound1Message2) RoundNumber() int  { return 1 }
+func (m *SignRound1Message2) IsBroadcast() bool { return true }
+
+func (m *SignRound1Message2) ValidateBasic() bool {
+	return m != nil && m.Commitment != nil
+}
+
+func (m *SignRound2Message) RoundNumber() int  { return 2 }
+func (m *SignRound2Message) IsBroadcast() bool { return false }
+
+func (m *SignRound2Message) ValidateBasic() bool {
+	return m != nil &&
+		m.C1 != nil &&
+		curve.NonEmptyMultiBytes(m.ProofBob, mta.ProofBobBytesParts) &&
+		m.C2 != nil &&
+		curve.NonEmptyMultiBytes(m.ProofBobWC, mta.ProofBobWCBytesParts)
+}
+
+// Hash returns the message hash used in culprit reports
+func (m *SignRound2Message) Hash() []byte {
+	parts := append([][]byte{m.C1.Bytes()}, m.ProofBob...)
+	parts = append(parts, m.C2.Bytes())
+	return cmt.SHA512_256(append(parts, m.ProofBobWC...)...)
+}
+
+func (m *SignRound3Message) RoundNumber() int  { return 3 }
+func (m *SignRound3Message) IsBroadcast() bool { return true }
+
+func (m *SignRound3Message) ValidateBasic() bool {
+	return m != nil && m.Delta != nil
+}
+
+func (m *SignRound4Message) RoundNumber() int  { return 4 }
+func (m *SignRound4Message) IsBroadcast() bool { return true }
+
+func (m *SignRound4Message) ValidateBasic() bool {
+	return m != nil && nonNilInts(m.DeCommitment) && curve.NonEmptyMultiBytes(m.GammaProof, schnorr.ZKProofBytesParts)
+}
+
+func (m *SignRound5Message) RoundNumber() int  { return 5 }
+func (m *SignRound5Message) IsBroadcast() bool { return true }
+
+func (m *SignRound5Message) ValidateBasic() bool {
+	return m != nil && m.Commitment != nil
+}
+
+func (m *SignRound6Message) RoundNumber() int  { return 6 }
+func (m *SignRound6Message) IsBroadcast() bool { return true }
+
+func (m *SignRound6Message) ValidateBasic() bool {
+	return m != nil &&
+		nonNilInts(m.DeCommitment) &&
+		curve.NonEmptyMultiBytes(m.VProof, schnorr.ZKVProofBytesParts) &&
+		curve.NonEmptyMultiBytes(m.AProof, schnorr.ZKProofBytesParts)
+}
+
+func (m *SignRound7Message) RoundNumber() int  { return 7 }
+func (m *SignRound7Message) IsBroadcast() bool { return true }
+
+func (m *SignRound7Message) ValidateBasic() bool {
+	return m != nil && m.Commitment != nil
+}
+
+func (m *SignRound8Message) RoundNumber() int  { return 8 }
+func (m *SignRound8Message) IsBroadcast() bool { return true }
+
+func (m *SignRound8Message) ValidateBasic() bool {
+	return m != nil && nonNilInts(m.DeCommitment) && curve.NonEmptyMultiBytes(m.UProof, schnorr.DLEQProofBytesParts)
+}
+
+func (m *SignRound9Message) RoundNumber() int  { return 9 }
+func (m *SignRound9Message) IsBroadcast() bool { return true }
+
+func (m *SignRound9Message) ValidateBasic() bool {
+	return m != nil && m.Si != nil
+}
+
+// ----- utils
+
+func nonNilInts(in []*big.Int) bool {
+	if len(in) == 0 {
+		return false
+	}
+	for _, v := range in {
+		if v == nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,475 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package signing
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"github.com/zhp12543/zk-proof/abort"
+	"github.com/zhp12543/zk-proof/cmt"
+	"github.com/zhp12543/zk-proof/curve"
+	"github.com/zhp12543/zk-proof/mta"
+	"github.com/zhp12543/zk-proof/schnorr"
+	"math/big"
+)
+
+// Round1 samples k_i and gamma_i, starts an MtA for k_i with every other signer and commits to Gamma_i = g^gamma_i
+func (p *LocalParty) Round1() ([]*Message, error) {
+	if p.round != 0 {
+		return nil, errors.New("signing: Round1 has already been run")
+	}
+	p.round = 1
+	ec, i, n := p.params.EC, p.params.Index, p.params.PartyCount()
+	q := ec.Params().N
+
+	p.temp.ki = curve.GetRandomPositiveInt(q)
+	p.temp.gammaI = curve.GetRandomPositiveInt(q)
+	p.temp.bigGammaI = curve.ScalarBaseMult(ec, p.temp.gammaI)
+	cmtDeCmt := cmt.NewHashCommitment(p.temp.bigGammaI.X(), p.temp.bigGammaI.Y())
+	p.temp.gammaDeCommit = cmtDeCmt.D
+
+	pk := &p.key.LocalPreParams.PaillierSK.PublicKey
+	p.temp.cAs = make([]*big.Int, n)
+	out := make([]*Message, 0, n)
+	for j := range p.params.PartyIDs {
+		if j == i {
+			continue
+		}
+		k := p.temp.keyIdx[j]
+		cA, pi, err := mta.AliceInit(ec, pk, p.temp.ki, p.key.NTildej[k], p.key.H1j[k], p.key.H2j[k], p.params.session(i))
+		if err != nil {
+			return nil, err
+		}
+		p.temp.cAs[j] = cA
+		bzs := pi.Bytes()
+		out = append(out, &Message{From: i, To: j, Content: &SignRound1Message1{C: cA, RangeProof: bzs[:]}})
+	}
+	out = append(out, &Message{From: i, To: Broadcast, Content: &SignRound1Message2{Commitment: cmtDeCmt.C}})
+	return out, nil
+}
+
+// Round2 answers every signer's MtA for k_j * gamma_i and, with check against W_i, k_j * w_i
+func (p *LocalParty) Round2() ([]*Message, error) {
+	if p.round != 1 || !p.canProceed() {
+		return nil, errors.New("signing: Round2 is not ready")
+	}
+	p.round = 2
+	ec, i, n := p.params.EC, p.params.Index, p.params.PartyCount()
+	NTildeI, h1I, h2I := p.ownRingPedersen()
+
+	p.temp.betas = make([]*big.Int, n)
+	p.temp.nus = make([]*big.Int, n)
+	out := make([]*Message, 0, n)
+	var culprits []*abort.Culprit
+	for j := range p.params.PartyIDs {
+		if j == i {
+			continue
+		}
+		k := p.temp.keyIdx[j]
+		r1msg := p.message(1, false, j).(*SignRound1Message1)
+		pfA, err := mta.RangeProofAliceFromBytes(r1msg.RangeProof)
+		if err != nil {
+			culprits = append(culprits, mtaCulprit(j, r1msg.Hash(), &mta.ProofError{Proof: "RangeProofAlice", Step: mta.StepDecode, Err: err}))
+			continue
+		}
+		pkJ := p.key.PaillierPKs[k]
+		NTildeJ, h1J, h2J := p.key.NTildej[k], p.key.H1j[k], p.key.H2j[k]
+		// both MtA instances answer the same range proof, so it is verified once
+		if err = mta.VerifyAliceInit(ec, pkJ, pfA, r1msg.C, NTildeI, h1I, h2I, p.params.session(j)); err != nil {
+			culprits = append(culprits, mtaCulprit(j, r1msg.Hash(), err))
+			continue
+		}
+		beta, c1, _, piB, err := mta.BobMidPreVerified(ec, pkJ, p.temp.gammaI, r1msg.C, NTildeJ, h1J, h2J, p.params.session(i))
+		if err != nil {
+			if c := mtaCulprit(j, r1msg.Hash(), err); c != nil {
+				culprits = append(culprits, c)
+				continue
+			}
+			return nil, fmt.Errorf("signing round 2: MtA with party %d failed: %v", j, err)
+		}
+		nu, c2, _, piBWC, err := mta.BobMidWCPreVerified(ec, pkJ, p.temp.wi, r1msg.C, NTildeJ, h1J, h2J, p.temp.bigWs[i], p.params.session(i))
+		if err != nil {
+			if c := mtaCulprit(j, r1msg.Hash(), err); c != nil {
+				culprits = append(culprits, c)
+				continue
+			}
+			return nil, fmt.Errorf("signing round 2: MtAwc with party %d failed: %v", j, err)
+		}
+		p.temp.betas[j], p.temp.nus[j] = beta, nu
+		piBBzs, piBWCBzs := piB.Bytes(), piBWC.Bytes()
+		out = append(out, &Message{
+			From: i,
+			To:   j,
+			Content: &SignRound2Message{
+				C1:         c1,
+				ProofBob:   piBBzs[:],
+				C2:         c2,
+				ProofBobWC: piBWCBzs[:],
+			},
+		})
+	}
+	if len(culprits) != 0 {
+		return nil, abort.NewError("signing", 2, culprits...)
+	}
+	return out, nil
+}
+
+// Round3 finishes our MtA instances and broadcasts delta_i; sigma_i is kept as our additive share of k * x
+func (p *LocalParty) Round3() ([]*Message, error) {
+	if p.round != 2 || !p.canProceed() {
+		return nil, errors.New("signing: Round3 is not ready")
+	}
+	p.round = 3
+	ec, i := p.params.EC, p.params.Index
+	q := ec.Params().N
+	NTildeI, h1I, h2I := p.ownRingPedersen()
+	sk := p.key.LocalPreParams.PaillierSK
+	pk := &sk.PublicKey
+
+	deltaI := new(big.Int).Mul(p.temp.ki, p.temp.gammaI)
+	sigmaI := new(big.Int).Mul(p.temp.ki, p.temp.wi)
+	var culprits []*abort.Culprit
+	for j := range p.params.PartyIDs {
+		if j == i {
+			continue
+		}
+		r2msg := p.message(2, false, j).(*SignRound2Message)
+		var alpha, mu *big.Int
+		piB, err := mta.ProofBobFromBytes(r2msg.ProofBob)
+		if err != nil {
+			err = &mta.ProofError{Proof: "ProofBob", Step: mta.StepDecode, Err: err}
+		} else {
+			alpha, err = mta.AliceEnd(ec, pk, piB, h1I, h2I, p.temp.cAs[j], r2msg.C1, NTildeI, sk, p.params.session(j))
+		}
+		if err != nil {
+			if c := mtaCulprit(j, r2msg.Hash(), err); c != nil {
+				culprits = append(culprits, c)
+				continue
+			}
+			return nil, fmt.Errorf("signing round 3: MtA with party %d failed: %v", j, err)
+		}
+		piBWC, err := mta.ProofBobWCFromBytes(ec, r2msg.ProofBobWC)
+		if err != nil {
+			err = &mta.ProofError{Proof: "ProofBobWC", Step: mta.StepDecode, Err: err}
+		} else {
+			mu, err = mta.AliceEndWC(ec, pk, piBWC, p.temp.bigWs[j], p.temp.cAs[j], r2msg.C2, NTildeI, h1I, h2I, sk, p.params.session(j))
+		}
+		if err != nil {
+			if c := mtaCulprit(j, r2msg.Hash(), err); c != nil {
+				culprits = append(culprits, c)
+				continue
+			}
+			return nil, fmt.Errorf("signing round 3: MtAwc with party %d failed: %v", j, err)
+		}
+		deltaI = deltaI.Add(deltaI, alpha)
+		deltaI = deltaI.Add(deltaI, p.temp.betas[j])
+		sigmaI = sigmaI.Add(sigmaI, mu)
+		sigmaI = sigmaI.Add(sigmaI, p.temp.nus[j])
+	}
+	if len(culprits) != 0 {
+		return nil, abort.NewError("signing", 3, culprits...)
+	}
+	p.temp.deltaI = deltaI.Mod(deltaI, q)
+	p.temp.sigmaI = sigmaI.Mod(sigmaI, q)
+	return []*Message{{From: i, To: Broadcast, Content: &SignRound3Message{Delta: p.temp.deltaI}}}, nil
+}
+
+// Round4 reconstructs delta = k * gamma and opens our commitment to Gamma_i
+func (p *LocalParty) Round4() ([]*Message, error) {
+	if p.round != 3 || !p.canProceed() {
+		return nil, errors.New("signing: Round4 is not ready")
+	}
+	p.round = 4
+	ec, i := p.params.EC, p.params.Index
+	q := ec.Params().N
+
+	delta := new(big.Int).Set(p.temp.deltaI)
+	for j := range p.params.PartyIDs {
+		if j == i {
+			continue
+		}
+		delta = delta.Add(delta, p.message(3, true, j).(*SignRound3Message).Delta)
+	}
+	if delta = delta.Mod(delta, q); delta.Sign() == 0 {
+		return nil, errors.New("signing round 4: delta is zero")
+	}
+	p.temp.delta = delta
+
+	pf, err := schnorr.NewZKProof(p.temp.gammaI, p.temp.bigGammaI, p.params.session(i))
+	if err != nil {
+		return nil, err
+	}
+	bzs := pf.Bytes()
+	return []*Message{{
+		From:    i,
+		To:      Broadcast,
+		Content: &SignRound4Message{DeCommitment: p.temp.gammaDeCommit, GammaProof: bzs[:]},
+	}}, nil
+}
+
+// Round5 computes R = Gamma^(delta^-1) and our signature share s_i, and commits to V_i = R^s_i * g^l_i and A_i = g^rho_i
+func (p *LocalParty) Round5() ([]*Message, error) {
+	if p.round != 4 || !p.canProceed() {
+		return nil, errors.New("signing: Round5 is not ready")
+	}
+	p.round = 5
+	ec, i := p.params.EC, p.params.Index
+	q := ec.Params().N
+
+	bigGamma := p.temp.bigGammaI
+	for j := range p.params.PartyIDs {
+		if j == i {
+			continue
+		}
+		r1msg := p.message(1, true, j).(*SignRound1Message2)
+		r4msg := p.message(4, true, j).(*SignRound4Message)
+		points, err := openPoints(ec, r1msg.Commitment, r4msg.DeCommitment, 1)
+		if err != nil {
+			return nil, fmt.Errorf("signing round 5: de-commitment from party %d failed: %v", j, err)
+		}
+		pf, err := schnorr.NewZKProofFromBytes(points[0], r4msg.GammaProof)
+		if err != nil || !pf.Verify(points[0], p.params.session(j)) {
+			return nil, fmt.Errorf("signing round 5: proof of gamma_j from party %d failed", j)
+		}
+		if bigGamma, err = bigGamma.Add(points[0]); err != nil {
+			return nil, err
+		}
+	}
+	deltaInv := new(big.Int).ModInverse(p.temp.delta, q)
+	p.temp.bigR = bigGamma.ScalarMult(deltaInv)
+	if p.temp.bigR == nil {
+		return nil, errors.New("signing round 5: R is the point at infinity")
+	}
+	if p.temp.r = new(big.Int).Mod(p.temp.bigR.X(), q); p.temp.r.Sign() == 0 {
+		return nil, errors.New("signing round 5: r is zero")
+	}
+
+	// s_i = m * k_i + r * sigma_i
+	si := new(big.Int).Mul(p.m, p.temp.ki)
+	si = si.Add(si, new(big.Int).Mul(p.temp.r, p.temp.sigmaI))
+	p.temp.si = si.Mod(si, q)
+
+	// phase 5A
+	p.temp.li = curve.GetRandomPositiveInt(q)
+	p.temp.rhoI = curve.GetRandomPositiveInt(q)
+	bigVi, err := p.temp.bigR.ScalarMult(p.temp.si).Add(curve.ScalarBaseMult(ec, p.temp.li))
+	if err != nil {
+		return nil, err
+	}
+	p.temp.bigVi = bigVi
+	p.temp.bigAi = curve.ScalarBaseMult(ec, p.temp.rhoI)
+	cmtDeCmt := cmt.NewHashCommitment(bigVi.X(), bigVi.Y(), p.temp.bigAi.X(), p.temp.bigAi.Y())
+	p.temp.vaDeCommit = cmtDeCmt.D
+	return []*Message{{From: i, To: Broadcast, Content: &SignRound5Message{Commitment: cmtDeCmt.C}}}, nil
+}
+
+// Round6 opens the commitment to V_i and A_i with proofs of knowledge of s_i, l_i and rho_i (phase 5B)
+func (p *LocalParty) Round6() ([]*Message, error) {
+	if p.round != 5 || !p.canProceed() {
+		return nil, errors.New("signing: Round6 is not ready")
+	}
+	p.round = 6
+	vProof, err := schnorr.NewZKVProof(p.temp.bigVi, p.temp.bigR, p.temp.si, p.temp.li, p.params.session(p.params.Index))
+	if err != nil {
+		return nil, err
+	}
+	aProof, err := schnorr.NewZKProof(p.temp.rhoI, p.temp.bigAi, p.params.session(p.params.Index))
+	if err != nil {
+		return nil, err
+	}
+	vBzs, aBzs := vProof.Bytes(), aProof.Bytes()
+	return []*Message{{
+		From:    p.params.Index,
+		To:      Broadcast,
+		Content: &SignRound6Message{DeCommitment: p.temp.vaDeCommit, VProof: vBzs[:], AProof: aBzs[:]},
+	}}, nil
+}
+
+// Round7 computes V = g^-m * y^-r * prod V_j and A = prod A_j and commits to U_i = V^rho_i and T_i = A^l_i (phase 5C)
+func (p *LocalParty) Round7() ([]*Message, error) {
+	if p.round != 6 || !p.canProceed() {
+		return nil, errors.New("signing: Round7 is not ready")
+	}
+	p.round = 7
+	ec, i := p.params.EC, p.params.Index
+	q := ec.Params().N
+
+	p.temp.bigAjs = make([]*curve.ECPoint, p.params.PartyCount())
+	p.temp.bigAjs[i] = p.temp.bigAi
+	bigV, bigA := p.temp.bigVi, p.temp.bigAi
+	for j := range p.params.PartyIDs {
+		if j == i {
+			continue
+		}
+		r5msg := p.message(5, true, j).(*SignRound5Message)
+		r6msg := p.message(6, true, j).(*SignRound6Message)
+		points, err := openPoints(ec, r5msg.Commitment, r6msg.DeCommitment, 2)
+		if err != nil {
+			return nil, fmt.Errorf("signing round 7: de-commitment from party %d failed: %v", j, err)
+		}
+		bigVj, bigAj := points[0], points[1]
+		vProof, err := schnorr.NewZKVProofFromBytes(p.temp.bigR, r6msg.VProof)
+		if err != nil || !vProof.Verify(bigVj, p.temp.bigR, p.params.session(j)) {
+			return nil, fmt.Errorf("signing round 7: proof of s_j, l_j from party %d failed", j)
+		}
+		aProof, err := schnorr.NewZKProofFromBytes(bigAj, r6msg.AProof)
+		if err != nil || !aProof.Verify(bigAj, p.params.session(j)) {
+			return nil, fmt.Errorf("signing round 7: proof of rho_j from party %d failed", j)
+		}
+		p.temp.bigAjs[j] = bigAj
+		if bigV, err = bigV.Add(bigVj); err != nil {
+			return nil, err
+		}
+		if bigA, err = bigA.Add(bigAj); err != nil {
+			return nil, err
+		}
+	}
+
+	// V = g^-m * y^-r * prod V_j
+	minusM := new(big.Int).Mod(p.m, q)
+	if minusM.Sign() != 0 {
+		var err error
+		if bigV, err = bigV.Add(curve.ScalarBaseMult(ec, minusM.Sub(q, minusM))); err != nil {
+			return nil, err
+		}
+	}
+	minusR := new(big.Int).Sub(q, p.temp.r)
+	bigV, err := bigV.Add(p.key.ECDSAPub.ScalarMult(minusR))
+	if err != nil {
+		return nil, err
+	}
+	p.temp.bigV, p.temp.bigA = bigV, bigA
+
+	p.temp.bigUi = bigV.ScalarMult(p.temp.rhoI)
+	p.temp.bigTi = bigA.ScalarMult(p.temp.li)
+	if p.temp.bigUi == nil || p.temp.bigTi == nil {
+		return nil, errors.New("signing round 7: U_i or T_i is the point at infinity")
+	}
+	cmtDeCmt := cmt.NewHashCommitment(p.temp.bigUi.X(), p.temp.bigUi.Y(), p.temp.bigTi.X(), p.temp.bigTi.Y())
+	p.temp.utDeCommit = cmtDeCmt.D
+	return []*Message{{From: i, To: Broadcast, Content: &SignRound7Message{Commitment: cmtDeCmt.C}}}, nil
+}
+
+// Round8 opens the commitment to U_i and T_i with a proof that U_i and A_i share rho_i (phase 5D)
+func (p *LocalParty) Round8() ([]*Message, error) {
+	if p.round != 7 || !p.canProceed() {
+		return nil, errors.New("signing: Round8 is not ready")
+	}
+	p.round = 8
+	st := &schnorr.DLEQStatement{G: generator(p.params.EC), H: p.temp.bigV, A: p.temp.bigAi, B: p.temp.bigUi}
+	pf, err := schnorr.NewDLEQProof(p.temp.rhoI, st, p.params.session(p.params.Index))
+	if err != nil {
+		return nil, err
+	}
+	bzs := pf.Bytes()
+	return []*Message{{
+		From:    p.params.Index,
+		To:      Broadcast,
+		Content: &SignRound8Message{DeCommitment: p.temp.utDeCommit, UProof: bzs[:]},
+	}}, nil
+}
+
+// Round9 checks prod U_j = prod T_j, which holds only if every s_j is consistent with R and the public key,
+// and then reveals s_i (phase 5E)
+func (p *LocalParty) Round9() ([]*Message, error) {
+	if p.round != 8 || !p.canProceed() {
+		return nil, errors.New("signing: Round9 is not ready")
+	}
+	p.round = 9
+	ec, i := p.params.EC, p.params.Index
+	g := generator(ec)
+
+	sumU, sumT := p.temp.bigUi, p.temp.bigTi
+	for j := range p.params.PartyIDs {
+		if j == i {
+			continue
+		}
+		r7msg := p.message(7, true, j).(*SignRound7Message)
+		r8msg := p.message(8, true, j).(*SignRound8Message)
+		points, err := openPoints(ec, r7msg.Commitment, r8msg.DeCommitment, 2)
+		if err != nil {
+			return nil, fmt.Errorf("signing round 9: de-commitment from party %d failed: %v", j, err)
+		}
+		bigUj, bigTj := points[0], points[1]
+		st := &schnorr.DLEQStatement{G: g, H: p.temp.bigV, A: p.temp.bigAjs[j], B: bigUj}
+		pf, err := schnorr.NewDLEQProofFromBytes(st, r8msg.UProof)
+		if err != nil || !pf.Verify(st, p.params.session(j)) {
+			return nil, fmt.Errorf("signing round 9: proof of U_j from party %d failed", j)
+		}
+		if sumU, err = sumU.Add(bigUj); err != nil {
+			return nil, err
+		}
+		if sumT, err = sumT.Add(bigTj); err != nil {
+			return nil, err
+		}
+	}
+	if !sumU.Equals(sumT) {
+		return nil, errors.New("signing round 9: phase 5 check failed, prod U_j != prod T_j")
+	}
+	return []*Message{{From: i, To: Broadcast, Content: &SignRound9Message{Si: p.temp.si}}}, nil
+}
+
+// finish sums the signature shares and checks the signature against the public key
+func (p *LocalParty) finish() error {
+	ec, i := p.params.EC, p.params.Index
+	q := ec.Params().N
+	s := new(big.Int).Set(p.temp.si)
+	for j := range p.params.PartyIDs {
+		if j == i {
+			continue
+		}
+		s = s.Add(s, p.message(9, true, j).(*SignRound9Message).Si)
+	}
+	if s = s.Mod(s, q); s.Sign() == 0 {
+		return errors.New("signing finish: s is zero")
+	}
+	pub := &ecdsa.PublicKey{Curve: ec, X: p.key.ECDSAPub.X(), Y: p.key.ECDSAPub.Y()}
+	if !ecdsa.Verify(pub, p.m.Bytes(), p.temp.r, s) {
+		return errors.New("signing finish: the signature does not verify")
+	}
+	p.data = SignatureData{R: p.temp.r, S: s, M: p.m}
+	p.done = true
+	return nil
+}
+
+// ----- utils
+
+// mtaCulprit turns an MtA error caused by party j's message into a culprit report, or returns nil if we are at fault
+func mtaCulprit(j int, msgHash []byte, err error) *abort.Culprit {
+	var pe *mta.ProofError
+	if !errors.As(err, &pe) {
+		return nil
+	}
+	c := &abort.Culprit{Party: j, Proof: pe.Proof, Step: pe.Step, MsgHash: msgHash}
+	if pe.Err != nil {
+		c.Detail = pe.Err.Error()
+	}
+	return c
+}
+
+func (p *LocalParty) ownRingPedersen() (NTilde, h1, h2 *big.Int) {
+	pre := p.key.LocalPreParams
+	return pre.NTildei, pre.H1i, pre.H2i
+}
+
+// openPoints checks a hash commitment to `count` curve points and returns them
+func openPoints(ec elliptic.Curve, C *big.Int, D cmt.HashDeCommitment, count int) ([]*curve.ECPoint, error) {
+	ok, flat := (&cmt.HashCommitDecommit{C: C, D: D}).DeCommit()
+	if !ok {
+		return nil, errors.New("the de-commitment does not match the commitment")
+	}
+	if len(flat) != 2*count {
+		return nil, fmt.Errorf("expected %d committed points but got %d values", count, len(flat))
+	}
+	return curve.UnFlattenECPoints(ec, flat)
+}
+
+func generator(ec elliptic.Curve) *curve.ECPoint {
+	return curve.NewECPointNoCurveCheck(ec, ec.Params().Gx, ec.Params().Gy)
+}
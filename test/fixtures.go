@@ -0,0 +1,71 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+// Package test holds fixtures and in-process protocol runners shared by the protocol tests. Generating
+// production-sized pre-params takes minutes, so they are generated once and kept in _fixtures.
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/zhp12543/zk-proof/proof"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+const (
+	preParamsFixtureFileFormat = "pre_params_%d.json"
+	preParamsTimeout           = 10 * time.Minute
+)
+
+// LoadPreParams returns the fixture pre-params with the given index, generating and saving them if missing
+func LoadPreParams(index int) (*proof.PaillierParams, error) {
+	path := filepath.Join(fixturesDir(), fmt.Sprintf(preParamsFixtureFileFormat, index))
+	bz, err := ioutil.ReadFile(path)
+	if err == nil {
+		params := new(proof.PaillierParams)
+		if err = json.Unmarshal(bz, params); err != nil {
+			return nil, fmt.Errorf("LoadPreParams: could not parse %s: %v", path, err)
+		}
+		return params, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	params, err := proof.GeneratePreParams(preParamsTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if bz, err = json.Marshal(params); err != nil {
+		return nil, err
+	}
+	if err = ioutil.WriteFile(path, bz, 0600); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// LoadPreParamsN returns the fixture pre-params with indexes start..start+n-1
+func LoadPreParamsN(start, n int) ([]*proof.PaillierParams, error) {
+	out := make([]*proof.PaillierParams, n)
+	for i := range out {
+		params, err := LoadPreParams(start + i)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = params
+	}
+	return out, nil
+}
+
+func fixturesDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "_fixtures")
+}
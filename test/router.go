@@ -0,0 +1,43 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package test
+
+import (
+	"fmt"
+)
+
+// Route delivers the queued messages in FIFO order until no party has anything left to send.
+// `to` lists the parties a message is delivered to, and `update` hands it to one of them and returns that party's replies.
+func Route[M any](queue []M, to func(M) []int, update func(party int, msg M) ([]M, error)) error {
+	for len(queue) != 0 {
+		msg := queue[0]
+		queue = queue[1:]
+		for _, j := range to(msg) {
+			out, err := update(j, msg)
+			if err != nil {
+				return fmt.Errorf("party %d: %w", j, err)
+			}
+			queue = append(queue, out...)
+		}
+	}
+	return nil
+}
+
+// Recipients returns the parties out of n that receive a message from party `from` to party `to`.
+// A negative `to` is a broadcast to everyone but the sender; a negative `from` is a sender outside the n parties.
+func Recipients(n, from, to int) []int {
+	if 0 <= to {
+		return []int{to}
+	}
+	out := make([]int, 0, n)
+	for j := 0; j < n; j++ {
+		if j != from {
+			out = append(out, j)
+		}
+	}
+	return out
+}
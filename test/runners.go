@@ -0,0 +1,129 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package test
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"github.com/zhp12543/zk-proof/keygen"
+	"github.com/zhp12543/zk-proof/signing"
+	"math/big"
+)
+
+// NewSessionID returns a random session ID for one protocol run
+func NewSessionID() ([]byte, error) {
+	id := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// RunKeygen runs an in-process keygen between n parties with IDs 1..n, using the fixture pre-params 0..n-1
+func RunKeygen(ec elliptic.Curve, n, threshold int) ([]*keygen.LocalPartySaveData, error) {
+	preParams, err := LoadPreParamsN(0, n)
+	if err != nil {
+		return nil, err
+	}
+	sessionID, err := NewSessionID()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]*big.Int, n)
+	for i := range ids {
+		ids[i] = big.NewInt(int64(i + 1))
+	}
+	parties := make([]*keygen.LocalParty, n)
+	var queue []*keygen.Message
+	for i := range parties {
+		params, err := keygen.NewParameters(ec, ids, i, threshold, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		if parties[i], err = keygen.NewLocalParty(params, preParams[i]); err != nil {
+			return nil, err
+		}
+		out, err := parties[i].Round1()
+		if err != nil {
+			return nil, err
+		}
+		queue = append(queue, out...)
+	}
+	err = Route(queue, func(msg *keygen.Message) []int {
+		return Recipients(n, msg.From, msg.To)
+	}, func(j int, msg *keygen.Message) ([]*keygen.Message, error) {
+		return parties[j].Update(msg)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("keygen %w", err)
+	}
+	keys := make([]*keygen.LocalPartySaveData, n)
+	for i, party := range parties {
+		data, ok := party.Outputs()
+		if !ok {
+			return nil, fmt.Errorf("keygen party %d did not finish", i)
+		}
+		keys[i] = data
+	}
+	return keys, nil
+}
+
+// RunSigning signs m with the given keys and returns every signer's output.
+// If tamper is not nil it may modify each message as it is sent.
+func RunSigning(ec elliptic.Curve, keys []*keygen.LocalPartySaveData, m *big.Int, tamper func(*signing.Message)) ([]*signing.SignatureData, error) {
+	send := func(out []*signing.Message) []*signing.Message {
+		if tamper != nil {
+			for _, msg := range out {
+				tamper(msg)
+			}
+		}
+		return out
+	}
+	sessionID, err := NewSessionID()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]*big.Int, len(keys))
+	for i, key := range keys {
+		ids[i] = key.ShareID
+	}
+	parties := make([]*signing.LocalParty, len(keys))
+	var queue []*signing.Message
+	for i := range parties {
+		params, err := signing.NewParameters(ec, ids, i, keys[i].Threshold, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		if parties[i], err = signing.NewLocalParty(params, keys[i], m); err != nil {
+			return nil, err
+		}
+		out, err := parties[i].Round1()
+		if err != nil {
+			return nil, err
+		}
+		queue = append(queue, send(out)...)
+	}
+	err = Route(queue, func(msg *signing.Message) []int {
+		return Recipients(len(parties), msg.From, msg.To)
+	}, func(j int, msg *signing.Message) ([]*signing.Message, error) {
+		out, err := parties[j].Update(msg)
+		return send(out), err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("signing %w", err)
+	}
+	sigs := make([]*signing.SignatureData, len(parties))
+	for i, party := range parties {
+		sig, ok := party.Outputs()
+		if !ok {
+			return nil, fmt.Errorf("signing party %d did not finish", i)
+		}
+		sigs[i] = sig
+	}
+	return sigs, nil
+}
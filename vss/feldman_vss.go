@@ -0,0 +1,223 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+// Feldman VSS, based on Paul Feldman, 1987., A practical scheme for non-interactive verifiable secret sharing.
+// In Foundations of Computer Science, 1987., 28th Annual Symposium on. IEEE, 427–43
+//
+// A secret is shared with a random polynomial of degree `threshold` mod the curve order,
+// so threshold+1 shares are needed to reconstruct it.
+
+package vss
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"github.com/zhp12543/zk-proof/curve"
+	"math/big"
+)
+
+type (
+	Share struct {
+		Threshold int
+		ID,       // xi
+		Share *big.Int // Sigma i
+	}
+
+	Vs []*curve.ECPoint // v0..vt
+
+	Shares []*Share
+)
+
+var (
+	ErrNumSharesBelowThreshold = fmt.Errorf("not enough shares to satisfy the threshold")
+)
+
+// Create returns the Feldman commitments to the polynomial and one share for each of the given indexes
+func Create(ec elliptic.Curve, threshold int, secret *big.Int, indexes []*big.Int) (Vs, Shares, error) {
+	if secret == nil || indexes == nil {
+		return nil, nil, errors.New("vss secret or indexes == nil")
+	}
+	if threshold < 1 {
+		return nil, nil, errors.New("vss threshold < 1")
+	}
+	if err := CheckIndexes(ec, indexes); err != nil {
+		return nil, nil, err
+	}
+	num := len(indexes)
+	if num < threshold+1 {
+		return nil, nil, ErrNumSharesBelowThreshold
+	}
+
+	poly := samplePolynomial(ec, threshold, secret)
+	v := make(Vs, len(poly))
+	for i, ai := range poly {
+		v[i] = curve.ScalarBaseMult(ec, ai)
+	}
+
+	shares := make(Shares, num)
+	for i := 0; i < num; i++ {
+		share := evaluatePolynomial(ec, poly, indexes[i])
+		shares[i] = &Share{Threshold: threshold, ID: indexes[i], Share: share}
+	}
+	return v, shares, nil
+}
+
+// Verify checks g^share = prod v_j^(id^j)
+func (share *Share) Verify(ec elliptic.Curve, threshold int, vs Vs) bool {
+	if share == nil || share.ID == nil || share.Share == nil || share.Threshold != threshold || len(vs) != threshold+1 {
+		return false
+	}
+	for _, v := range vs {
+		if !v.ValidateBasic() {
+			return false
+		}
+	}
+	sigmaGi := curve.ScalarBaseMult(ec, new(big.Int).Mod(share.Share, ec.Params().N))
+	if sigmaGi == nil {
+		return false
+	}
+	vsi, ok := evaluateCommitments(ec, vs, share.ID)
+	return ok && sigmaGi.Equals(vsi)
+}
+
+// ReConstruct recovers the secret from at least threshold+1 shares with Lagrange interpolation at 0
+func (shares Shares) ReConstruct(ec elliptic.Curve) (secret *big.Int, err error) {
+	if len(shares) == 0 || shares[0] == nil || len(shares) <= shares[0].Threshold {
+		return nil, ErrNumSharesBelowThreshold
+	}
+	xs := make([]*big.Int, 0, len(shares))
+	for _, share := range shares {
+		if share == nil || share.ID == nil || share.Share == nil {
+			return nil, errors.New("vss ReConstruct found a nil share")
+		}
+		xs = append(xs, share.ID)
+	}
+	if err = CheckIndexes(ec, xs); err != nil {
+		return nil, err
+	}
+	q := ec.Params().N
+	secret = big.NewInt(0)
+	for i, share := range shares {
+		lambda := LagrangeCoefficient(ec, xs, i)
+		term := new(big.Int).Mul(share.Share, lambda)
+		secret = secret.Add(secret, term)
+		secret = secret.Mod(secret, q)
+	}
+	return secret, nil
+}
+
+// Flat flattens the commitments with FlattenECPoints for transport
+func (vs Vs) Flat() ([]*big.Int, error) {
+	return curve.FlattenECPoints(vs)
+}
+
+func VsUnFlat(ec elliptic.Curve, in []*big.Int) (Vs, error) {
+	points, err := curve.UnFlattenECPoints(ec, in)
+	if err != nil {
+		return nil, err
+	}
+	return Vs(points), nil
+}
+
+// EvaluateAt returns prod v_j^(id^j), the public counterpart g^f(id) of the share with the given id
+func (vs Vs) EvaluateAt(ec elliptic.Curve, id *big.Int) (*curve.ECPoint, error) {
+	if len(vs) == 0 || id == nil {
+		return nil, errors.New("vss EvaluateAt received empty commitments or a nil id")
+	}
+	point, ok := evaluateCommitments(ec, vs, id)
+	if !ok {
+		return nil, errors.New("vss EvaluateAt hit the point at infinity")
+	}
+	return point, nil
+}
+
+// CheckIndexes returns an error if any index is 0 mod q or two indexes are equal mod q
+func CheckIndexes(ec elliptic.Curve, indexes []*big.Int) error {
+	q := ec.Params().N
+	visited := make(map[string]struct{}, len(indexes))
+	for _, v := range indexes {
+		if v == nil {
+			return errors.New("vss found a nil index")
+		}
+		vMod := new(big.Int).Mod(v, q)
+		if vMod.Sign() == 0 {
+			return errors.New("party index should not be 0")
+		}
+		vModStr := vMod.String()
+		if _, ok := visited[vModStr]; ok {
+			return fmt.Errorf("duplicate indexes %s", vModStr)
+		}
+		visited[vModStr] = struct{}{}
+	}
+	return nil
+}
+
+// LagrangeCoefficient returns prod_(j != i) xs[j] / (xs[j] - xs[i]) mod q, the weight of share i when interpolating at 0
+func LagrangeCoefficient(ec elliptic.Curve, xs []*big.Int, i int) *big.Int {
+	q := ec.Params().N
+	num, den := big.NewInt(1), big.NewInt(1)
+	for j, xj := range xs {
+		if j == i {
+			continue
+		}
+		num = num.Mul(num, xj)
+		num = num.Mod(num, q)
+		diff := new(big.Int).Sub(xj, xs[i])
+		den = den.Mul(den, diff)
+		den = den.Mod(den, q)
+	}
+	den = den.ModInverse(den, q)
+	num = num.Mul(num, den)
+	return num.Mod(num, q)
+}
+
+// ----- utils
+
+// samplePolynomial returns the coefficients a_0 = secret, a_1..a_threshold of a random polynomial mod q
+func samplePolynomial(ec elliptic.Curve, threshold int, secret *big.Int) []*big.Int {
+	q := ec.Params().N
+	v := make([]*big.Int, threshold+1)
+	v[0] = new(big.Int).Mod(secret, q)
+	for i := 1; i <= threshold; i++ {
+		ai := curve.GetRandomPositiveInt(q)
+		v[i] = ai
+	}
+	return v
+}
+
+// evaluatePolynomial computes sum a_i * id^i mod q with Horner's method
+func evaluatePolynomial(ec elliptic.Curve, poly []*big.Int, id *big.Int) *big.Int {
+	q := ec.Params().N
+	result := new(big.Int).Set(poly[len(poly)-1])
+	for i := len(poly) - 2; i >= 0; i-- {
+		result = result.Mul(result, id)
+		result = result.Add(result, poly[i])
+		result = result.Mod(result, q)
+	}
+	return result
+}
+
+// evaluateCommitments computes prod vs_j^(id^j); it fails only if an intermediate point is the identity
+func evaluateCommitments(ec elliptic.Curve, vs []*curve.ECPoint, id *big.Int) (*curve.ECPoint, bool) {
+	q := ec.Params().N
+	t := new(big.Int).Mod(id, q)
+	c := big.NewInt(1)
+	result := vs[0]
+	for j := 1; j < len(vs); j++ {
+		c = c.Mul(c, t)
+		c = c.Mod(c, q)
+		vj := vs[j].ScalarMult(c)
+		if vj == nil {
+			return nil, false
+		}
+		var err error
+		if result, err = result.Add(vj); err != nil {
+			return nil, false
+		}
+	}
+	return result, true
+}
@@ -0,0 +1,120 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+// Pedersen VSS, based on Torben Pryds Pedersen, 1991., Non-Interactive and Information-Theoretic Secure
+// Verifiable Secret Sharing. In Advances in Cryptology — CRYPTO '91, 129–140
+//
+// Unlike Feldman VSS the commitments C_j = g^a_j * H^b_j hide the secret unconditionally.
+// H must be a second generator whose discrete log with respect to g is unknown to the dealer.
+
+package vss
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"github.com/zhp12543/zk-proof/curve"
+	"math/big"
+)
+
+type (
+	PedersenShare struct {
+		Threshold int
+		ID,       // xi
+		Share, // f(xi)
+		Blinding *big.Int // f'(xi)
+	}
+
+	PedersenShares []*PedersenShare
+)
+
+// CreatePedersen returns the Pedersen commitments to the polynomial and one share for each of the given indexes
+func CreatePedersen(ec elliptic.Curve, H *curve.ECPoint, threshold int, secret *big.Int, indexes []*big.Int) (Vs, PedersenShares, error) {
+	if secret == nil || indexes == nil {
+		return nil, nil, errors.New("vss secret or indexes == nil")
+	}
+	if !H.ValidateBasic() || H.IsIdentity() {
+		return nil, nil, errors.New("vss H is not a valid generator")
+	}
+	if threshold < 1 {
+		return nil, nil, errors.New("vss threshold < 1")
+	}
+	if err := CheckIndexes(ec, indexes); err != nil {
+		return nil, nil, err
+	}
+	num := len(indexes)
+	if num < threshold+1 {
+		return nil, nil, ErrNumSharesBelowThreshold
+	}
+
+	poly := samplePolynomial(ec, threshold, secret)
+	blinding := samplePolynomial(ec, threshold, curve.GetRandomPositiveInt(ec.Params().N))
+	cs := make(Vs, len(poly))
+	for i := range poly {
+		ci, ok := pedersenCommit(ec, H, poly[i], blinding[i])
+		if !ok {
+			return nil, nil, errors.New("vss commitment is the identity")
+		}
+		cs[i] = ci
+	}
+
+	shares := make(PedersenShares, num)
+	for i := 0; i < num; i++ {
+		shares[i] = &PedersenShare{
+			Threshold: threshold,
+			ID:        indexes[i],
+			Share:     evaluatePolynomial(ec, poly, indexes[i]),
+			Blinding:  evaluatePolynomial(ec, blinding, indexes[i]),
+		}
+	}
+	return cs, shares, nil
+}
+
+// Verify checks g^share * H^blinding = prod cs_j^(id^j)
+func (share *PedersenShare) Verify(ec elliptic.Curve, H *curve.ECPoint, threshold int, cs Vs) bool {
+	if share == nil || share.ID == nil || share.Share == nil || share.Blinding == nil ||
+		share.Threshold != threshold || len(cs) != threshold+1 || !H.ValidateBasic() {
+		return false
+	}
+	for _, c := range cs {
+		if !c.ValidateBasic() {
+			return false
+		}
+	}
+	left, ok := pedersenCommit(ec, H, share.Share, share.Blinding)
+	if !ok {
+		return false
+	}
+	right, ok := evaluateCommitments(ec, cs, share.ID)
+	return ok && left.Equals(right)
+}
+
+// ReConstruct recovers the secret from at least threshold+1 shares; the blinding shares are not needed
+func (shares PedersenShares) ReConstruct(ec elliptic.Curve) (*big.Int, error) {
+	feldman := make(Shares, len(shares))
+	for i, share := range shares {
+		if share == nil {
+			return nil, errors.New("vss ReConstruct found a nil share")
+		}
+		feldman[i] = &Share{Threshold: share.Threshold, ID: share.ID, Share: share.Share}
+	}
+	return feldman.ReConstruct(ec)
+}
+
+// ----- utils
+
+func pedersenCommit(ec elliptic.Curve, H *curve.ECPoint, a, b *big.Int) (*curve.ECPoint, bool) {
+	q := ec.Params().N
+	gA := curve.ScalarBaseMult(ec, new(big.Int).Mod(a, q))
+	hB := H.ScalarMult(new(big.Int).Mod(b, q))
+	if gA == nil || hB == nil {
+		return nil, false
+	}
+	c, err := gA.Add(hB)
+	if err != nil || c.IsIdentity() {
+		return nil, false
+	}
+	return c, true
+}
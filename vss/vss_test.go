@@ -0,0 +1,107 @@
+// Copyright © 2019 Binance
+//
+// This file is part of Binance. The full Binance copyright notice, including
+// terms governing use, modification, and redistribution, is contained in the
+// file LICENSE at the root of the source code distribution tree.
+
+package vss
+
+import (
+	"crypto/elliptic"
+	"github.com/decred/dcrd/dcrec/edwards"
+	"github.com/zhp12543/zk-proof/curve"
+	"math/big"
+	"testing"
+)
+
+func testIndexes(ec elliptic.Curve, num int) []*big.Int {
+	ids := make([]*big.Int, num)
+	for i := range ids {
+		ids[i] = curve.GetRandomPositiveInt(ec.Params().N)
+	}
+	return ids
+}
+
+func TestFeldmanVSS(t *testing.T) {
+	for _, ec := range []elliptic.Curve{elliptic.P256(), edwards.Edwards()} {
+		name := ec.Params().Name
+		threshold, num := 2, 5
+		secret := curve.GetRandomPositiveInt(ec.Params().N)
+		ids := testIndexes(ec, num)
+
+		vs, shares, err := Create(ec, threshold, secret, ids)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		if len(vs) != threshold+1 || len(shares) != num {
+			t.Fatal(name, "unexpected number of commitments or shares")
+		}
+		flat, err := vs.Flat()
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		if vs, err = VsUnFlat(ec, flat); err != nil {
+			t.Fatal(name, err)
+		}
+		for _, share := range shares {
+			if !share.Verify(ec, threshold, vs) {
+				t.Fatal(name, "share did not verify")
+			}
+		}
+		tampered := &Share{Threshold: threshold, ID: shares[0].ID, Share: new(big.Int).Add(shares[0].Share, big.NewInt(1))}
+		if tampered.Verify(ec, threshold, vs) {
+			t.Error(name, "tampered share verified")
+		}
+
+		secret2, err := shares[:threshold+1].ReConstruct(ec)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		if secret2.Cmp(secret) != 0 {
+			t.Error(name, "reconstructed the wrong secret")
+		}
+		if _, err := shares[:threshold].ReConstruct(ec); err != ErrNumSharesBelowThreshold {
+			t.Error(name, "reconstructed from too few shares")
+		}
+	}
+}
+
+func TestCheckIndexes(t *testing.T) {
+	ec := elliptic.P256()
+	if err := CheckIndexes(ec, []*big.Int{big.NewInt(1), new(big.Int).Add(ec.Params().N, big.NewInt(1))}); err == nil {
+		t.Error("duplicate indexes mod q were accepted")
+	}
+	if _, _, err := Create(ec, 1, big.NewInt(7), []*big.Int{big.NewInt(1), ec.Params().N}); err == nil {
+		t.Error("an index of 0 mod q was accepted")
+	}
+}
+
+func TestPedersenVSS(t *testing.T) {
+	ec := elliptic.P256()
+	threshold, num := 2, 5
+	H := curve.ScalarBaseMult(ec, curve.GetRandomPositiveInt(ec.Params().N))
+	secret := curve.GetRandomPositiveInt(ec.Params().N)
+	ids := testIndexes(ec, num)
+
+	cs, shares, err := CreatePedersen(ec, H, threshold, secret, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, share := range shares {
+		if !share.Verify(ec, H, threshold, cs) {
+			t.Fatal("Pedersen share did not verify")
+		}
+	}
+	tampered := *shares[1]
+	tampered.Blinding = new(big.Int).Add(tampered.Blinding, big.NewInt(1))
+	if tampered.Verify(ec, H, threshold, cs) {
+		t.Error("tampered Pedersen share verified")
+	}
+	secret2, err := shares[1:].ReConstruct(ec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret2.Cmp(secret) != 0 {
+		t.Error("reconstructed the wrong secret")
+	}
+}